@@ -6,9 +6,10 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -34,6 +35,23 @@ type Session struct {
 // App struct
 type App struct {
 	ctx             context.Context
+	mu              sync.RWMutex
+	configDir       string
+	config          appConfig
+	workspace       *Workspace
+	workspaces      map[string]*Workspace
+	jobsMu          sync.Mutex
+	jobs            map[string]*generateJob
+	jobQueues       map[string][]*generateJob
+	jobRunners      map[string]bool
+	jobHistory      []*generateJob
+	watchMu         sync.Mutex
+	watcher         *protoWatcher
+	editor          editorState
+	streamsMu       sync.Mutex
+	streams         map[string]*rpcStream
+	saveMu          sync.Mutex
+	versions        *versionStore
 	users           map[string]User
 	sessions        map[string]Session
 	userDataFile    string
@@ -43,8 +61,14 @@ type App struct {
 // NewApp creates a new App application struct
 func NewApp() *App {
 	return &App{
-		users:    make(map[string]User),
-		sessions: make(map[string]Session),
+		workspaces: make(map[string]*Workspace),
+		jobs:       make(map[string]*generateJob),
+		jobQueues:  make(map[string][]*generateJob),
+		jobRunners: make(map[string]bool),
+		versions:   newVersionStore(),
+		streams:    make(map[string]*rpcStream),
+		users:      make(map[string]User),
+		sessions:   make(map[string]Session),
 	}
 }
 
@@ -52,39 +76,90 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+
+	configDir, err := appConfigDir()
+	if err != nil {
+		fmt.Printf("Error locating config directory: %v\n", err)
+	} else {
+		a.configDir = configDir
+		a.config = loadAppConfig(configDir)
+	}
+
+	a.resolveWorkspace()
 	a.initUserData()
 }
 
-// initUserData initializes user data storage
-func (a *App) initUserData() {
-	// Get application root directory
-	appRoot, err := os.Getwd()
-	if err != nil {
-		fmt.Printf("Error getting current directory: %v\n", err)
-		appRoot = "/Users/fangke/Documents/project/golang/pb-tool/pb-tool"
+// shutdown is called when the app is closing
+func (a *App) shutdown(ctx context.Context) {
+	a.stopWatching()
+	a.cancelStreams()
+}
+
+// domReady is called once the frontend has loaded. If no workspace could
+// be resolved at startup the user is asked to pick one.
+func (a *App) domReady(ctx context.Context) {
+	if _, err := a.currentWorkspace(); err == nil {
+		return
+	}
+	if _, err := a.ChooseWorkspace(); err != nil {
+		fmt.Printf("Error choosing workspace: %v\n", err)
 	}
+}
 
-	// Ensure we're in the correct directory
-	if _, err := os.Stat("main.go"); os.IsNotExist(err) {
-		appRoot = "/Users/fangke/Documents/project/golang/pb-tool/pb-tool"
+// initUserData initializes user data storage
+func (a *App) initUserData() {
+	if a.configDir == "" {
+		return
 	}
 
 	// Set data file paths
-	a.userDataFile = filepath.Join(appRoot, "data", "users.json")
-	a.sessionDataFile = filepath.Join(appRoot, "data", "sessions.json")
+	dataDir := filepath.Join(a.configDir, "data")
+	a.userDataFile = filepath.Join(dataDir, "users.json")
+	a.sessionDataFile = filepath.Join(dataDir, "sessions.json")
 
 	// Create data directory if it doesn't exist
-	dataDir := filepath.Join(appRoot, "data")
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		fmt.Printf("Error creating data directory: %v\n", err)
 		return
 	}
 
+	// Earlier versions kept the data in the working directory
+	if cwd, err := os.Getwd(); err == nil {
+		migrateUserData(filepath.Join(cwd, "data"), dataDir)
+	}
+
 	// Load existing user data
 	a.loadUserData()
 	a.loadSessionData()
 }
 
+// migrateUserData copies users.json and sessions.json from the legacy data
+// directory into dataDir, once: files already in dataDir are kept
+func migrateUserData(legacyDir, dataDir string) {
+	if legacyDir == dataDir {
+		return
+	}
+	for _, name := range []string{"users.json", "sessions.json"} {
+		target := filepath.Join(dataDir, name)
+		if _, err := os.Stat(target); !os.IsNotExist(err) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(legacyDir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Error reading legacy data file %s: %v\n", filepath.Join(legacyDir, name), err)
+			continue
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			fmt.Printf("Error migrating %s, still found in %s: %v\n", name, legacyDir, err)
+			continue
+		}
+		fmt.Printf("Migrated %s from %s to %s\n", name, legacyDir, dataDir)
+	}
+}
+
 // loadUserData loads user data from file
 func (a *App) loadUserData() {
 	// Check if file exists
@@ -172,466 +247,188 @@ func (a *App) generateID() string {
 	return hex.EncodeToString(bytes)
 }
 
-// ReadPB reads protobuf content from file
-func (a *App) ReadPB(filename string) string {
-	// Get application root directory
-	appRoot, err := os.Getwd()
+// ReadPB reads protobuf content from file along with its version, to be
+// passed back to SavePB. filename is a slash-separated path relative to
+// the workspace pb directory.
+func (a *App) ReadPB(filename string) (*PBFile, error) {
+	ws, err := a.currentWorkspace()
 	if err != nil {
-		return fmt.Sprintf("Error getting current directory: %v", err)
-	}
-
-	// Ensure we're in the correct directory
-	if _, err := os.Stat("main.go"); os.IsNotExist(err) {
-		appRoot = "/Users/fangke/Documents/project/golang/pb-tool/pb-tool"
+		return nil, err
 	}
 
 	// Read protobuf content from file
-	filePath := filepath.Join(appRoot, "pb", filename)
-	content, err := os.ReadFile(filePath)
+	filePath, err := ws.resolvePB(filename)
 	if err != nil {
-		return fmt.Sprintf("Error reading file %s: %v", filePath, err)
+		return nil, err
 	}
-
-	return string(content)
-}
-
-// SavePB saves protobuf content to file
-func (a *App) SavePB(filename, content string) string {
-	// Get application root directory (where main.go is located)
-	appRoot, err := os.Getwd()
+	content, version, err := readVersion(filePath)
 	if err != nil {
-		return fmt.Sprintf("Error getting current directory: %v", err)
-	}
-
-	// Ensure we're in the correct directory
-	// Check if main.go exists in current directory
-	if _, err := os.Stat("main.go"); os.IsNotExist(err) {
-		// If main.go not found, try to find it in parent directories
-		appRoot = "/Users/fangke/Documents/project/golang/pb-tool/pb-tool"
-	}
-
-	// Create pb directory if not exists
-	pbDir := filepath.Join(appRoot, "pb")
-	if err := os.MkdirAll(pbDir, 0755); err != nil {
-		return fmt.Sprintf("Error creating pb directory %s: %v", pbDir, err)
-	}
-
-	// Write protobuf content to file
-	filePath := filepath.Join(pbDir, filename)
-
-	// Check if we have write permission
-	if _, err := os.Stat(pbDir); err != nil {
-		return fmt.Sprintf("Error accessing pb directory %s: %v", pbDir, err)
-	}
-
-	// Write content to file
-	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
-		return fmt.Sprintf("Error saving file %s: %v", filePath, err)
+		return nil, fmt.Errorf("reading file %s: %w", filePath, err)
 	}
+	a.versions.remember(filePath, version, content)
+	a.setEditorBase(ws, filename, content)
 
-	// Verify file was created and get file info
-	fileInfo, err := os.Stat(filePath)
-	if err != nil {
-		return fmt.Sprintf("File %s was not created successfully: %v", filePath, err)
-	}
+	return &PBFile{Path: cleanProtoPath(filename), Content: string(content), Version: version}, nil
+}
 
-	// List files in pb directory to verify
-	pbFiles, err := os.ReadDir(pbDir)
+// SavePB saves protobuf content to file. filename is a slash-separated
+// path relative to the workspace pb directory. version is the version the
+// content was based on, as returned by ReadPB or the last SavePB; if the
+// file changed on disk since, nothing is written and the result describes
+// the conflict. An empty version saves unconditionally. With format on
+// save enabled for the workspace, content is formatted first; content that
+// does not parse is saved as is.
+func (a *App) SavePB(filename, content, version string) (*SaveResult, error) {
+	ws, err := a.currentWorkspace()
 	if err != nil {
-		return fmt.Sprintf("File saved successfully: %s\n"+
-			"File size: %d bytes\n"+
-			"Current directory: %s\n"+
-			"Error listing pb directory: %v",
-			filePath, fileInfo.Size(), appRoot, err)
-	}
-
-	// Create file list string
-	fileList := "Files in pb directory:"
-	for _, file := range pbFiles {
-		fileList += fmt.Sprintf("\n  - %s", file.Name())
+		return nil, err
 	}
-
-	return fmt.Sprintf("File saved successfully: %s\n"+
-		"File size: %d bytes\n"+
-		"Current directory: %s\n"+
-		"%s",
-		filePath, fileInfo.Size(), appRoot, fileList)
+	return a.savePB(ws, filename, content, version)
 }
 
-// GenerateGRPC generates golang grpc code from protobuf
-func (a *App) GenerateGRPC(filename, content string) string {
-	// Get application root directory
-	appRoot, err := os.Getwd()
-	if err != nil {
-		return fmt.Sprintf("Error getting current directory: %v", err)
+// savePB is SavePB for the proto of a given workspace. Generation jobs
+// save through it too.
+func (a *App) savePB(ws *Workspace, filename, content, version string) (*SaveResult, error) {
+	var formatErr error
+	formatted := false
+	if ws.Config.FormatOnSave {
+		var output string
+		if output, formatErr = formatProto(content); formatErr == nil && output != content {
+			content, formatted = output, true
+		}
 	}
 
-	// Ensure we're in the correct directory
-	if _, err := os.Stat("main.go"); os.IsNotExist(err) {
-		appRoot = "/Users/fangke/Documents/project/golang/pb-tool/pb-tool"
+	filePath, err := ws.resolveProtoFile(filename)
+	if err != nil {
+		return nil, err
 	}
 
-	// First save the protobuf file
-	pbDir := filepath.Join(appRoot, "pb")
+	// Create pb directory (and any subdirectories) if not exists
+	pbDir := filepath.Dir(filePath)
 	if err := os.MkdirAll(pbDir, 0755); err != nil {
-		return fmt.Sprintf("Error creating pb directory %s: %v", pbDir, err)
+		return nil, fmt.Errorf("creating pb directory %s: %w", pbDir, err)
 	}
 
-	filePath := filepath.Join(pbDir, filename)
-	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
-		return fmt.Sprintf("Error saving file %s: %v", filePath, err)
-	}
-
-	// Create output directory
-	outputDir := filepath.Join(appRoot, "grpc_output")
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Sprintf("Error creating output directory %s: %v", outputDir, err)
-	}
-
-	// Check if protoc is installed - first try project local protoc
-	protocPath := filepath.Join(appRoot, "bin", "protoc")
-	absProtocPath := protocPath
-
-	// Check if local protoc exists
-	if _, err := os.Stat(protocPath); os.IsNotExist(err) {
-		// Try to find protoc in system paths
-		if systemProtocPath, err := exec.LookPath("protoc"); err == nil {
-			protocPath = systemProtocPath
-			absProtocPath = systemProtocPath
-		} else {
-			return fmt.Sprintf("File saved successfully: %s\n"+
-				"Note: protoc compiler not found. GRPC code generation skipped.\n"+
-				"Local protoc: %s (not found)\n"+
-				"To enable GRPC code generation, please install protoc and try again.\n"+
-				"For macOS: brew install protobuf\n"+
-				"For Ubuntu: apt install -y protobuf-compiler\n"+
-				"For Windows: Download from https://github.com/protocolbuffers/protobuf/releases", filePath, absProtocPath)
-		}
-	}
-
-	// Set explicit plugin paths
-	protocGenGoPath := "$HOME/go/bin/protoc-gen-go"
-	protocGenGoGrpcPath := "$HOME/go/bin/protoc-gen-go-grpc"
-
-	// Expand environment variables
-	protocGenGoPath = os.ExpandEnv(protocGenGoPath)
-	protocGenGoGrpcPath = os.ExpandEnv(protocGenGoGrpcPath)
-
-	// Check if plugins exist
-	if _, err := os.Stat(protocGenGoPath); err != nil {
-		return fmt.Sprintf("File saved successfully: %s\n"+
-			"Warning: protoc-gen-go plugin not found at %s\n"+
-			"Please install it with: go install google.golang.org/protobuf/cmd/protoc-gen-go@latest",
-			filePath, protocGenGoPath)
-	}
-
-	if _, err := os.Stat(protocGenGoGrpcPath); err != nil {
-		return fmt.Sprintf("File saved successfully: %s\n"+
-			"Warning: protoc-gen-go-grpc plugin not found at %s\n"+
-			"Please install it with: go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest",
-			filePath, protocGenGoGrpcPath)
-	}
-
-	// Create a temporary script to set PATH and run protoc
-	tempScript := fmt.Sprintf(`#!/bin/bash
-set -e
-
-# Add go/bin to PATH
-export PATH="$HOME/go/bin:$PATH"
-
-# Set PROTOC_INCLUDE with all necessary paths
-# 1. Local include directory
-# 2. Google APIs from grpc-gateway
-# 3. System protoc include directory (for descriptor.proto)
-export PROTOC_INCLUDE="%s/include:/Users/fangke/go/pkg/mod/github.com/grpc-ecosystem/grpc-gateway@v1.16.0/third_party/googleapis:/opt/homebrew/include"
-
-# Change to application root directory
-cd "%s"
-
-# Run protoc with the given arguments
-"%s" --proto_path=".:${PROTOC_INCLUDE}" "$@"
-`, appRoot, appRoot, protocPath)
-
-	scriptPath := filepath.Join(appRoot, "run_protoc.sh")
-	if err := os.WriteFile(scriptPath, []byte(tempScript), 0755); err != nil {
-		return fmt.Sprintf("File saved successfully: %s\n"+
-			"Warning: Error creating temporary script %s: %v", filePath, scriptPath, err)
-	}
-	defer os.Remove(scriptPath)
-
-	// Debug information for protoc command - declared early for use in plugin checks
-	debugInfo := fmt.Sprintf("\nDebug Information:\n"+
-		"App Root: %s\n"+
-		"PB File Path: %s\n"+
-		"Output Directory: %s\n"+
-		"Protoc Path: %s\n"+
-		"Script Path: %s\n"+
-		"Protoc Gen Go Path: %s\n"+
-		"Protoc Gen Go GRPC Path: %s\n",
-		appRoot, filePath, outputDir, protocPath, scriptPath, protocGenGoPath, protocGenGoGrpcPath)
-
-	// Check for grpc-gateway plugin
-	protocGenGrpcGatewayPath := "$HOME/go/bin/protoc-gen-grpc-gateway"
-	protocGenGrpcGatewayPath = os.ExpandEnv(protocGenGrpcGatewayPath)
-	grpcGatewayEnabled := true
-	if _, err := os.Stat(protocGenGrpcGatewayPath); err != nil {
-		grpcGatewayEnabled = false
-		debugInfo += fmt.Sprintf("\ngRPC Gateway plugin not found at %s, skipping gateway generation", protocGenGrpcGatewayPath)
-	}
-
-	// Create simple test to verify output directory is writable
-	testFile := filepath.Join(outputDir, "test.txt")
-	if err := os.WriteFile(testFile, []byte("test"), 0644); err != nil {
-		debugInfo += fmt.Sprintf("\nError writing test file to output directory: %v", err)
-	} else {
-		debugInfo += fmt.Sprintf("\nSuccessfully wrote test file to output directory")
-		os.Remove(testFile) // Clean up test file
-	}
+	// Check the version and write under one lock, so saves from this app
+	// cannot interleave
+	a.saveMu.Lock()
+	defer a.saveMu.Unlock()
 
-	// List output directory before generation
-	beforeFiles, err := os.ReadDir(outputDir)
-	if err == nil {
-		debugInfo += "\nFiles in output directory before generation:"
-		for _, file := range beforeFiles {
-			debugInfo += fmt.Sprintf("\n  - %s", file.Name())
+	if version != "" {
+		disk, diskVersion, err := readVersion(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("reading file %s: %w", filePath, err)
+		}
+		if versionHash(diskVersion) != versionHash(version) {
+			conflict := &SaveConflict{
+				Path:        cleanProtoPath(filename),
+				BaseVersion: version,
+				DiskVersion: diskVersion,
+				Disk:        string(disk),
+			}
+			if base, ok := a.versions.content(filePath, version); ok {
+				conflict.Diff = diff3(base, content, string(disk))
+			}
+			if diskVersion != "" {
+				a.versions.remember(filePath, diskVersion, disk)
+			}
+			return &SaveResult{
+				Path:     filePath,
+				Version:  version,
+				Message:  fmt.Sprintf("File %s changed on disk since it was loaded; not saved", filePath),
+				Conflict: conflict,
+			}, nil
 		}
-	} else {
-		debugInfo += fmt.Sprintf("\nError listing output directory before generation: %v", err)
 	}
 
-	// Get relative pb file path from app root
-	relPbPath, err := filepath.Rel(appRoot, filePath)
-	if err != nil {
-		relPbPath = filepath.Base(filePath) // Fallback to just the filename
+	// Write content to file
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return nil, fmt.Errorf("saving file %s: %w", filePath, err)
 	}
+	a.setEditorBase(ws, filename, []byte(content))
 
-	// Define all variables at function level
-	var finalErr error
-	var finalOutput string
-	var finalCmdString string
-	var cmdErr error
-	var cmdErr2 error
-	var cmdErr3 error
-
-	// Get all proto files in pb directory
-	pbFiles, err := filepath.Glob(filepath.Join(appRoot, "pb", "*.proto"))
+	// Verify file was created and get its new version
+	saved, newVersion, err := readVersion(filePath)
 	if err != nil {
-		debugInfo += fmt.Sprintf("\nError finding proto files: %v", err)
-		return fmt.Sprintf("Error finding proto files: %v\n%s", err, debugInfo)
+		return nil, fmt.Errorf("file %s was not created successfully: %w", filePath, err)
 	}
+	a.versions.remember(filePath, newVersion, saved)
 
-	// Convert to relative paths
-	relPbFiles := make([]string, len(pbFiles))
-	for i, file := range pbFiles {
-		rel, err := filepath.Rel(appRoot, file)
-		if err != nil {
-			rel = filepath.Base(file)
-		}
-		relPbFiles[i] = rel
+	result := &SaveResult{
+		Saved:   true,
+		Path:    filePath,
+		Size:    int64(len(saved)),
+		Version: newVersion,
+		Message: fmt.Sprintf("File saved successfully: %s\nFile size: %d bytes\nWorkspace: %s", filePath, len(saved), ws.Root),
 	}
-
-	// Simplified approach: just use one command with source_relative for all proto files
-	// This is the most reliable way for simple cases
-	cmdArgs := []string{
-		fmt.Sprintf("--go_out=paths=source_relative:%s", outputDir),
-		fmt.Sprintf("--go-grpc_out=paths=source_relative:%s", outputDir),
+	if formatted {
+		result.Formatted = true
+		result.Content = content
 	}
-	cmdArgs = append(cmdArgs, relPbFiles...)
-
-	debugInfo += fmt.Sprintf("\nTrying approach: Simple source_relative")
-
-	// First approach
-	cmd1 := exec.Command(scriptPath, cmdArgs...)
-	output1, err1 := cmd1.CombinedOutput()
-	cmdErr = err1
-	finalOutput = string(output1)
-	finalErr = cmdErr
-	finalCmdString = cmd1.String()
-
-	if cmdErr == nil {
-		debugInfo += fmt.Sprintf("\n✅ Approach succeeded! Output: %s", finalOutput)
-	} else {
-		debugInfo += fmt.Sprintf("\n❌ Approach failed with error: %v\nOutput: %s", cmdErr, finalOutput)
+	if formatErr != nil {
+		result.Message += fmt.Sprintf("\nNot formatted: %v", formatErr)
 	}
+	return result, nil
+}
 
-	// If error, try another approach with explicit proto_path
-	if cmdErr != nil {
-		debugInfo += fmt.Sprintf("\nTrying approach: With explicit proto_path")
-		cmdArgs2 := []string{
-			"--proto_path=.",
-			fmt.Sprintf("--go_out=paths=source_relative:%s", outputDir),
-			fmt.Sprintf("--go-grpc_out=paths=source_relative:%s", outputDir),
-		}
-		cmdArgs2 = append(cmdArgs2, relPbFiles...)
-
-		cmd2 := exec.Command(scriptPath, cmdArgs2...)
-		output2, err2 := cmd2.CombinedOutput()
-		cmdErr2 = err2
-		finalOutput = string(output2)
-		finalErr = cmdErr2
-		finalCmdString = cmd2.String()
-
-		if cmdErr2 == nil {
-			debugInfo += fmt.Sprintf("\n✅ Approach succeeded! Output: %s", finalOutput)
-		} else {
-			debugInfo += fmt.Sprintf("\n❌ Approach failed with error: %v\nOutput: %s", cmdErr2, finalOutput)
-		}
+// GetGeneratedFiles returns a list of generated files, including those in
+// subdirectories. Names are slash-separated paths relative to the output
+// directory.
+func (a *App) GetGeneratedFiles() []map[string]interface{} {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil
 	}
 
-	// If still error, try with M option
-	if finalErr != nil {
-		debugInfo += fmt.Sprintf("\nTrying approach: With M option")
-		cmdArgs3 := []string{
-			fmt.Sprintf("--go_out=paths=source_relative:%s", outputDir),
-			fmt.Sprintf("--go-grpc_out=paths=source_relative:%s", outputDir),
-		}
-		// Add M options for each proto file
-		for _, file := range relPbFiles {
-			cmdArgs3 = append(cmdArgs3, fmt.Sprintf("--go_opt=M%s=.", file))
-			cmdArgs3 = append(cmdArgs3, fmt.Sprintf("--go-grpc_opt=M%s=.", file))
-		}
-		cmdArgs3 = append(cmdArgs3, relPbFiles...)
-
-		cmd3 := exec.Command(scriptPath, cmdArgs3...)
-		output3, err3 := cmd3.CombinedOutput()
-		cmdErr3 = err3
-		finalOutput = string(output3)
-		finalErr = cmdErr3
-		finalCmdString = cmd3.String()
-
-		if cmdErr3 == nil {
-			debugInfo += fmt.Sprintf("\n✅ Approach succeeded! Output: %s", finalOutput)
-		} else {
-			debugInfo += fmt.Sprintf("\n❌ Approach failed with error: %v\nOutput: %s", cmdErr3, finalOutput)
+	// Walk the output directory
+	outputDir := ws.OutputPath()
+	var fileList []map[string]interface{}
+	err = filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
-	}
-
-	if finalErr != nil {
-		return fmt.Sprintf("File saved successfully: %s\n"+
-			"Warning: Error generating GRPC code: %v\n"+
-			"Output: %s\n"+
-			"Command: %s\n"+
-			"%s",
-			filePath, finalErr, finalOutput, finalCmdString, debugInfo)
-	}
-
-	// List files in output directory to verify
-	afterFiles, err := os.ReadDir(outputDir)
-	fileList := ""
-	if err == nil {
-		fileList = "\nFiles in grpc_output directory after generation:"
-		for _, file := range afterFiles {
-			fileList += fmt.Sprintf("\n  - %s", file.Name())
-			// Get file size
-			if fileInfo, err := file.Info(); err == nil {
-				fileList += fmt.Sprintf(" (size: %d bytes)", fileInfo.Size())
+		if d.IsDir() {
+			if path != outputDir && skipTreeDir(d.Name()) {
+				return filepath.SkipDir
 			}
+			return nil
 		}
-	} else {
-		fileList = fmt.Sprintf("\nError listing output directory after generation: %v", err)
-	}
-
-	// Generate gRPC Gateway code if enabled
-	if grpcGatewayEnabled {
-		debugInfo += fmt.Sprintf("\n\n🔄 Generating gRPC Gateway code...")
-
-		// Create gateway output directory
-		gatewayOutputDir := outputDir
-
-		// Define gateway command arguments
-		gatewayCmdArgs := []string{
-			fmt.Sprintf("--grpc-gateway_out=paths=source_relative:%s", gatewayOutputDir),
-			"--grpc-gateway_opt=grpc_api_configuration=gateway.yaml",
-			"--grpc-gateway_opt=allow_delete_body=true",
-			relPbPath,
+		fileInfo, err := d.Info()
+		if err != nil {
+			return nil
 		}
-
-		// Run gateway generation command
-		gatewayCmd := exec.Command(scriptPath, gatewayCmdArgs...)
-		gatewayOutput, gatewayErr := gatewayCmd.CombinedOutput()
-
-		if gatewayErr == nil {
-			debugInfo += fmt.Sprintf("\n✅ gRPC Gateway code generated successfully! Output: %s", string(gatewayOutput))
-
-			// Update file list with gateway files
-			afterGatewayFiles, _ := os.ReadDir(gatewayOutputDir)
-			fileList += "\n\nFiles after gRPC Gateway generation:"
-			for _, file := range afterGatewayFiles {
-				fileList += fmt.Sprintf("\n  - %s", file.Name())
-				// Get file size
-				if fileInfo, err := file.Info(); err == nil {
-					fileList += fmt.Sprintf(" (size: %d bytes)", fileInfo.Size())
-				}
-			}
-		} else {
-			debugInfo += fmt.Sprintf("\n⚠️  gRPC Gateway code generation failed: %v\nOutput: %s", gatewayErr, string(gatewayOutput))
-			debugInfo += "\nNote: gRPC Gateway requires proper proto annotations and gateway.yaml configuration"
+		rel, err := filepath.Rel(outputDir, path)
+		if err != nil {
+			return err
 		}
-	}
-
-	return fmt.Sprintf("File saved successfully: %s\n"+
-		"GRPC code generated successfully! Output saved to %s directory\n"+
-		"Output: %s\n"+
-		"%s%s", filePath, outputDir, finalOutput, debugInfo, fileList)
-}
-
-// GetGeneratedFiles returns a list of generated files
-func (a *App) GetGeneratedFiles() []map[string]interface{} {
-	// Get application root directory
-	appRoot, err := os.Getwd()
-	if err != nil {
+		fileList = append(fileList, map[string]interface{}{
+			"name":     filepath.ToSlash(rel),
+			"size":     fileInfo.Size(),
+			"modified": fileInfo.ModTime().Format("2006-01-02 15:04:05"),
+		})
 		return nil
-	}
-
-	// Ensure we're in the correct directory
-	if _, err := os.Stat("main.go"); os.IsNotExist(err) {
-		appRoot = "/Users/fangke/Documents/project/golang/pb-tool/pb-tool"
-	}
-
-	// Get output directory - files are actually in grpc_output/pb
-	outputDir := filepath.Join(appRoot, "grpc_output", "pb")
-
-	// Read files in output directory
-	files, err := os.ReadDir(outputDir)
+	})
 	if err != nil {
 		return nil
 	}
 
-	// Create file list
-	var fileList []map[string]interface{}
-	for _, file := range files {
-		if !file.IsDir() {
-			fileInfo, err := file.Info()
-			if err != nil {
-				continue
-			}
-			fileList = append(fileList, map[string]interface{}{
-				"name":     file.Name(),
-				"size":     fileInfo.Size(),
-				"modified": fileInfo.ModTime().Format("2006-01-02 15:04:05"),
-			})
-		}
-	}
-
 	return fileList
 }
 
-// ReadGeneratedFile reads the content of a generated file
+// ReadGeneratedFile reads the content of a generated file, named by its
+// slash-separated path relative to the output directory
 func (a *App) ReadGeneratedFile(filename string) string {
-	// Get application root directory
-	appRoot, err := os.Getwd()
+	ws, err := a.currentWorkspace()
 	if err != nil {
-		return fmt.Sprintf("Error getting current directory: %v", err)
+		return fmt.Sprintf("Error: %v", err)
 	}
 
-	// Ensure we're in the correct directory
-	if _, err := os.Stat("main.go"); os.IsNotExist(err) {
-		appRoot = "/Users/fangke/Documents/project/golang/pb-tool/pb-tool"
+	// Get file path, which must stay inside the output directory
+	filePath, err := resolveInside(ws.OutputPath(), filename, "generated file", "the output directory")
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err)
 	}
 
-	// Get file path - files are actually in grpc_output/pb
-	filePath := filepath.Join(appRoot, "grpc_output", "pb", filename)
-
 	// Read file content
 	content, err := os.ReadFile(filePath)
 	if err != nil {
@@ -647,24 +444,16 @@ func (a *App) DownloadGeneratedFile(filename string) string {
 	return a.ReadGeneratedFile(filename)
 }
 
-// GetPBFiles returns a list of protobuf files in the pb directory
+// GetPBFiles returns a list of protobuf files under the pb directory.
+// Names are slash-separated paths relative to the pb directory.
 func (a *App) GetPBFiles() []map[string]interface{} {
-	// Get application root directory
-	appRoot, err := os.Getwd()
+	ws, err := a.currentWorkspace()
 	if err != nil {
 		return nil
 	}
 
-	// Ensure we're in the correct directory
-	if _, err := os.Stat("main.go"); os.IsNotExist(err) {
-		appRoot = "/Users/fangke/Documents/project/golang/pb-tool/pb-tool"
-	}
-
-	// Get pb directory
-	pbDir := filepath.Join(appRoot, "pb")
-
-	// Read files in pb directory
-	files, err := os.ReadDir(pbDir)
+	// Find proto files in pb directory and its subdirectories
+	files, err := ws.ProtoFiles()
 	if err != nil {
 		return nil
 	}
@@ -672,17 +461,15 @@ func (a *App) GetPBFiles() []map[string]interface{} {
 	// Create file list
 	var fileList []map[string]interface{}
 	for _, file := range files {
-		if !file.IsDir() && filepath.Ext(file.Name()) == ".proto" {
-			fileInfo, err := file.Info()
-			if err != nil {
-				continue
-			}
-			fileList = append(fileList, map[string]interface{}{
-				"name":     file.Name(),
-				"size":     fileInfo.Size(),
-				"modified": fileInfo.ModTime().Format("2006-01-02 15:04:05"),
-			})
+		fileInfo, err := os.Stat(ws.protoPath(file))
+		if err != nil {
+			continue
 		}
+		fileList = append(fileList, map[string]interface{}{
+			"name":     file,
+			"size":     fileInfo.Size(),
+			"modified": fileInfo.ModTime().Format("2006-01-02 15:04:05"),
+		})
 	}
 
 	return fileList
@@ -0,0 +1,860 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Breaking change categories. WIRE changes break binary messages or gRPC
+// calls between peers built from the baseline and the current protos;
+// WIRE_JSON changes break only their JSON encoding; SOURCE changes break
+// code generated from the baseline but not the encoding; HTTP changes
+// alter the google.api.http bindings.
+const (
+	BreakingWire     = "WIRE"
+	BreakingWireJSON = "WIRE_JSON"
+	BreakingSource   = "SOURCE"
+	BreakingHTTP     = "HTTP"
+)
+
+// Baseline kinds accepted by CheckBreaking
+const (
+	BaselineSnapshot = "snapshot"
+	BaselineGit      = "git"
+	BaselineFile     = "file"
+)
+
+// baselineDir holds the saved baselines, relative to the workspace root
+var baselineDir = filepath.Join(".pb-tool", "baselines")
+
+// baselineNamePattern restricts baseline names to safe file names
+var baselineNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Baseline is a saved FileDescriptorSet of the workspace protos
+type Baseline struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Files     int       `json:"files"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BreakingChange is an incompatible difference between the baseline and
+// the current protos. Subject is the full name of the changed element.
+// File, Line and Column locate it in the current protos; for removed
+// elements File is the baseline file and Line is zero.
+type BreakingChange struct {
+	Category string `json:"category"`
+	Subject  string `json:"subject"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
+// BreakingReport lists the breaking changes found against a baseline, with
+// their number per category
+type BreakingReport struct {
+	Baseline   string           `json:"baseline"`
+	Changes    []BreakingChange `json:"changes"`
+	Categories map[string]int   `json:"categories"`
+}
+
+// String formats a change as "file:line:col: [CATEGORY] message"
+func (c BreakingChange) String() string {
+	prefix := c.File
+	if c.Line > 0 {
+		prefix = fmt.Sprintf("%s:%d:%d", c.File, c.Line, c.Column)
+	}
+	return fmt.Sprintf("%s: [%s] %s", prefix, c.Category, c.Message)
+}
+
+// saveBaseline writes the compiled workspace protos, without their imports,
+// as the baseline name
+func (w *Workspace) saveBaseline(ctx context.Context, name string) (*Baseline, error) {
+	if !baselineNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid baseline name %q", name)
+	}
+	compiled, err := w.CompileAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range compiled {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	data, err := proto.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(w.Root, baselineDir, name+".binpb")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("writing baseline %s: %w", path, err)
+	}
+	return &Baseline{Name: name, Path: path, Files: len(set.File), CreatedAt: time.Now()}, nil
+}
+
+// baselines returns the saved baselines, newest first
+func (w *Workspace) baselines() ([]Baseline, error) {
+	dir := filepath.Join(w.Root, baselineDir)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Baseline{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	baselines := []Baseline{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".binpb")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		baseline := Baseline{Name: name, Path: filepath.Join(dir, entry.Name()), CreatedAt: info.ModTime()}
+		if set, err := readDescriptorSet(baseline.Path); err == nil {
+			baseline.Files = len(set.File)
+		}
+		baselines = append(baselines, baseline)
+	}
+	sort.Slice(baselines, func(i, j int) bool {
+		return baselines[i].CreatedAt.After(baselines[j].CreatedAt)
+	})
+	return baselines, nil
+}
+
+// readDescriptorSet reads a binary FileDescriptorSet
+func readDescriptorSet(path string) (*descriptorpb.FileDescriptorSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("parsing FileDescriptorSet %s: %w", path, err)
+	}
+	return set, nil
+}
+
+// descriptorSetFiles links a FileDescriptorSet. Imports missing from the
+// set become placeholders, so sets built without their imports load too.
+// Each file is linked with its own imports only, as compile does, so files
+// never imported together may define the same symbols.
+func descriptorSetFiles(set *descriptorpb.FileDescriptorSet) ([]protoreflect.FileDescriptor, error) {
+	byName := make(map[string]*descriptorpb.FileDescriptorProto, len(set.File))
+	for _, fdp := range set.File {
+		byName[fdp.GetName()] = fdp
+	}
+
+	var fds []protoreflect.FileDescriptor
+	for _, fdp := range set.File {
+		closure := &descriptorpb.FileDescriptorSet{}
+		seen := make(map[string]bool)
+		var visit func(name string)
+		visit = func(name string) {
+			dep, ok := byName[name]
+			if !ok || seen[name] {
+				return
+			}
+			seen[name] = true
+			for _, imp := range dep.GetDependency() {
+				visit(imp)
+			}
+			closure.File = append(closure.File, dep)
+		}
+		visit(fdp.GetName())
+
+		files, err := protodesc.FileOptions{AllowUnresolvable: true}.NewFiles(closure)
+		if err != nil {
+			return nil, err
+		}
+		if fd, err := files.FindFileByPath(fdp.GetName()); err == nil {
+			fds = append(fds, fd)
+		}
+	}
+	return fds, nil
+}
+
+// gitBaseline compiles the workspace protos as they are at a git ref
+func (w *Workspace) gitBaseline(ctx context.Context, ref string) ([]protoreflect.FileDescriptor, error) {
+	git := func(dir string, args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		output, err := cmd.Output()
+		if err != nil {
+			if exit, ok := err.(*exec.ExitError); ok {
+				return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+			}
+			return "", fmt.Errorf("running git: %w", err)
+		}
+		return string(output), nil
+	}
+
+	top, err := git(w.Root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	top = strings.TrimSpace(top)
+
+	// Every source root is read from the ref, so imports between roots
+	// resolve to the same version. A path in several roots is taken from
+	// the first, as on import.
+	overlay := make(map[string]string)
+	var files []string
+	for _, root := range w.sourceRoots() {
+		dir, err := filepath.EvalSymlinks(root)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		relRoot, err := filepath.Rel(top, dir)
+		if err != nil {
+			return nil, err
+		}
+		relRoot = filepath.ToSlash(relRoot)
+		if relRoot == ".." || strings.HasPrefix(relRoot, "../") {
+			continue
+		}
+
+		listing, err := git(top, "ls-tree", "-r", "--name-only", ref, "--", relRoot)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range strings.Split(listing, "\n") {
+			if !strings.HasSuffix(path, ".proto") {
+				continue
+			}
+			name := strings.TrimPrefix(path, relRoot+"/")
+			if relRoot == "." {
+				name = path
+			}
+			if _, ok := overlay[name]; ok || !w.ownsProto(root, name) {
+				continue
+			}
+			content, err := git(top, "show", ref+":"+path)
+			if err != nil {
+				return nil, err
+			}
+			overlay[name] = content
+			files = append(files, name)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no proto files in the source roots at %s", ref)
+	}
+
+	compiled, err := w.compile(ctx, files, overlay, nil)
+	if err != nil {
+		return nil, fmt.Errorf("compiling the protos at %s: %w", ref, err)
+	}
+	return linkedDescriptors(compiled), nil
+}
+
+// ownsProto reports whether the proto at the import path name belongs to
+// root, rather than to a nested root, an excluded or a hidden directory
+func (w *Workspace) ownsProto(root, name string) bool {
+	path := filepath.Join(root, filepath.FromSlash(name))
+	if w.excludedPath(path) || w.sourceRootOf(path) != root {
+		return false
+	}
+	parts := strings.Split(name, "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if skipTreeDir(dir) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDependency reports whether the proto at path comes from outside the
+// workspace sources: the include directory, a vendored buf dep or the
+// descriptors linked into pb-tool
+func (w *Workspace) isDependency(path string) bool {
+	for _, root := range w.sourceRoots() {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(path))); err == nil {
+			return false
+		}
+	}
+	if strings.HasPrefix(path, wellKnownPrefix) {
+		return true
+	}
+	if _, err := protoregistry.GlobalFiles.FindFileByPath(path); err == nil {
+		return true
+	}
+	dirs := []string{w.IncludePath()}
+	if w.Buf != nil {
+		for _, dep := range w.Buf.Deps {
+			if dep.Dir != "" {
+				dirs = append(dirs, w.path(filepath.FromSlash(dep.Dir)))
+			}
+		}
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(path))); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckBreaking compares the current protos with a baseline: the saved
+// snapshot named ref (the newest when empty), the protos at git ref, or
+// the FileDescriptorSet file at ref, relative to the workspace root
+func (w *Workspace) CheckBreaking(ctx context.Context, kind, ref string) (*BreakingReport, error) {
+	var baseline []protoreflect.FileDescriptor
+	var description string
+	switch kind {
+	case BaselineSnapshot:
+		if ref == "" {
+			saved, err := w.baselines()
+			if err != nil {
+				return nil, err
+			}
+			if len(saved) == 0 {
+				return nil, fmt.Errorf("no saved baseline")
+			}
+			ref = saved[0].Name
+		}
+		if !baselineNamePattern.MatchString(ref) {
+			return nil, fmt.Errorf("invalid baseline name %q", ref)
+		}
+		ref = filepath.Join(w.Root, baselineDir, ref+".binpb")
+		fallthrough
+	case BaselineFile:
+		set, err := readDescriptorSet(w.path(ref))
+		if err != nil {
+			return nil, err
+		}
+		if baseline, err = descriptorSetFiles(set); err != nil {
+			return nil, fmt.Errorf("loading baseline %s: %w", ref, err)
+		}
+		description = w.path(ref)
+	case BaselineGit:
+		var err error
+		if baseline, err = w.gitBaseline(ctx, ref); err != nil {
+			return nil, err
+		}
+		description = "git " + ref
+	default:
+		return nil, fmt.Errorf("unknown baseline kind %q", kind)
+	}
+
+	compiled, err := w.CompileAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Types of the dependencies are not ours to check
+	var own []protoreflect.FileDescriptor
+	for _, fd := range baseline {
+		if !w.isDependency(fd.Path()) {
+			own = append(own, fd)
+		}
+	}
+
+	c := newBreakingComparison(own, linkedDescriptors(compiled))
+	c.compare()
+	report := &BreakingReport{Baseline: description, Changes: c.changes, Categories: make(map[string]int)}
+	if report.Changes == nil {
+		report.Changes = []BreakingChange{}
+	}
+	for _, change := range report.Changes {
+		report.Categories[change.Category]++
+	}
+	return report, nil
+}
+
+// breakingComparison collects the changes between two sets of protos
+type breakingComparison struct {
+	baseline, current descriptorIndex
+	changes           []BreakingChange
+}
+
+// descriptorIndex holds the messages, enums and services of a set of files
+// by full name
+type descriptorIndex struct {
+	messages map[protoreflect.FullName]protoreflect.MessageDescriptor
+	enums    map[protoreflect.FullName]protoreflect.EnumDescriptor
+	services map[protoreflect.FullName]protoreflect.ServiceDescriptor
+}
+
+// newDescriptorIndex indexes the types declared in files, nested ones included
+func newDescriptorIndex(files []protoreflect.FileDescriptor) descriptorIndex {
+	index := descriptorIndex{
+		messages: make(map[protoreflect.FullName]protoreflect.MessageDescriptor),
+		enums:    make(map[protoreflect.FullName]protoreflect.EnumDescriptor),
+		services: make(map[protoreflect.FullName]protoreflect.ServiceDescriptor),
+	}
+	var addEnums func(enums protoreflect.EnumDescriptors)
+	addEnums = func(enums protoreflect.EnumDescriptors) {
+		for i := 0; i < enums.Len(); i++ {
+			index.enums[enums.Get(i).FullName()] = enums.Get(i)
+		}
+	}
+	var addMessages func(messages protoreflect.MessageDescriptors)
+	addMessages = func(messages protoreflect.MessageDescriptors) {
+		for i := 0; i < messages.Len(); i++ {
+			msg := messages.Get(i)
+			if msg.IsMapEntry() {
+				continue
+			}
+			index.messages[msg.FullName()] = msg
+			addMessages(msg.Messages())
+			addEnums(msg.Enums())
+		}
+	}
+	for _, fd := range files {
+		addMessages(fd.Messages())
+		addEnums(fd.Enums())
+		for i := 0; i < fd.Services().Len(); i++ {
+			index.services[fd.Services().Get(i).FullName()] = fd.Services().Get(i)
+		}
+	}
+	return index
+}
+
+// newBreakingComparison compares baseline with current
+func newBreakingComparison(baseline, current []protoreflect.FileDescriptor) *breakingComparison {
+	return &breakingComparison{baseline: newDescriptorIndex(baseline), current: newDescriptorIndex(current)}
+}
+
+// sortedNames returns the keys of m in order
+func sortedNames[T any](m map[protoreflect.FullName]T) []protoreflect.FullName {
+	names := make([]protoreflect.FullName, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// report records a change to the current element at, or to the removed
+// baseline element when at is nil
+func (c *breakingComparison) report(category string, subject protoreflect.Descriptor, at protoreflect.Descriptor, format string, args ...interface{}) {
+	change := BreakingChange{
+		Category: category,
+		Subject:  string(subject.FullName()),
+		File:     subject.ParentFile().Path(),
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if at != nil {
+		change.File = at.ParentFile().Path()
+		loc := at.ParentFile().SourceLocations().ByDescriptor(at)
+		if loc.StartLine > 0 || loc.StartColumn > 0 || loc.EndLine > 0 {
+			change.Line, change.Column = loc.StartLine+1, loc.StartColumn+1
+		}
+	}
+	c.changes = append(c.changes, change)
+}
+
+// compare walks the baseline types in name order
+func (c *breakingComparison) compare() {
+	for _, name := range sortedNames(c.baseline.messages) {
+		c.message(c.baseline.messages[name], c.current.messages[name])
+	}
+	for _, name := range sortedNames(c.baseline.enums) {
+		c.enum(c.baseline.enums[name], c.current.enums[name])
+	}
+	for _, name := range sortedNames(c.baseline.services) {
+		c.service(c.baseline.services[name], c.current.services[name])
+	}
+}
+
+// message compares the fields and reservations of a message
+func (c *breakingComparison) message(base, cur protoreflect.MessageDescriptor) {
+	if cur == nil {
+		c.report(BreakingSource, base, nil, "message %s was removed", base.FullName())
+		return
+	}
+
+	for i := 0; i < base.Fields().Len(); i++ {
+		bf := base.Fields().Get(i)
+		cf := cur.Fields().ByNumber(bf.Number())
+		if cf == nil {
+			switch moved := cur.Fields().ByName(bf.Name()); {
+			case moved != nil:
+				c.report(BreakingWire, bf, moved, "field %s changed number from %d to %d", bf.Name(), bf.Number(), moved.Number())
+			case !cur.ReservedRanges().Has(bf.Number()):
+				c.report(BreakingWire, bf, cur, "field %s (%d) was removed without reserving its number", bf.Name(), bf.Number())
+			case !cur.ReservedNames().Has(bf.Name()):
+				c.report(BreakingWireJSON, bf, cur, "field %s (%d) was removed without reserving its name", bf.Name(), bf.Number())
+			default:
+				c.report(BreakingSource, bf, cur, "field %s (%d) was removed", bf.Name(), bf.Number())
+			}
+			continue
+		}
+		c.field(bf, cf)
+	}
+
+	// New fields must not reuse what the baseline reserved
+	for i := 0; i < cur.Fields().Len(); i++ {
+		cf := cur.Fields().Get(i)
+		if base.ReservedRanges().Has(cf.Number()) {
+			c.report(BreakingWire, cf, cf, "field %s uses number %d reserved in the baseline", cf.Name(), cf.Number())
+		}
+		if base.ReservedNames().Has(cf.Name()) {
+			c.report(BreakingWireJSON, cf, cf, "field %s uses a name reserved in the baseline", cf.Name())
+		}
+	}
+	for i := 0; i < base.ReservedRanges().Len(); i++ {
+		r := base.ReservedRanges().Get(i)
+		if !rangesCover(cur.ReservedRanges(), r[0], r[1]) {
+			c.report(BreakingWire, cur, cur, "reserved numbers %s are no longer reserved", formatRange(r[0], r[1]-1))
+		}
+	}
+	for i := 0; i < base.ReservedNames().Len(); i++ {
+		if name := base.ReservedNames().Get(i); !cur.ReservedNames().Has(name) {
+			c.report(BreakingWireJSON, cur, cur, "reserved name %s is no longer reserved", name)
+		}
+	}
+}
+
+// field compares a field with the field of the same number
+func (c *breakingComparison) field(bf, cf protoreflect.FieldDescriptor) {
+	switch {
+	case bf.JSONName() != cf.JSONName():
+		c.report(BreakingWireJSON, cf, cf, "field %d changed its JSON name from %s to %s", bf.Number(), bf.JSONName(), cf.JSONName())
+	case bf.Name() != cf.Name():
+		c.report(BreakingSource, cf, cf, "field %d was renamed from %s to %s", bf.Number(), bf.Name(), cf.Name())
+	}
+
+	if bt, ct := fieldTypeName(bf), fieldTypeName(cf); bt != ct {
+		category := BreakingSource
+		switch {
+		case wireClass(bf) != wireClass(cf):
+			category = BreakingWire
+		case jsonClass(bf) != jsonClass(cf):
+			category = BreakingWireJSON
+		}
+		c.report(category, cf, cf, "field %s changed type from %s to %s", cf.Name(), bt, ct)
+	}
+
+	if cardinality(bf) != cardinality(cf) {
+		c.report(BreakingWire, cf, cf, "field %s changed from %s to %s", cf.Name(), cardinality(bf), cardinality(cf))
+	} else if bf.HasPresence() != cf.HasPresence() {
+		c.report(BreakingSource, cf, cf, "field %s changed presence tracking", cf.Name())
+	}
+
+	if bo, co := oneofName(bf), oneofName(cf); bo != co {
+		c.report(BreakingWire, cf, cf, "field %s moved from oneof %q to oneof %q", cf.Name(), bo, co)
+	}
+}
+
+// enum compares the values and reservations of an enum
+func (c *breakingComparison) enum(base, cur protoreflect.EnumDescriptor) {
+	if cur == nil {
+		c.report(BreakingSource, base, nil, "enum %s was removed", base.FullName())
+		return
+	}
+
+	for i := 0; i < base.Values().Len(); i++ {
+		bv := base.Values().Get(i)
+		cv := cur.Values().ByNumber(bv.Number())
+		if cv == nil {
+			switch moved := cur.Values().ByName(bv.Name()); {
+			case moved != nil:
+				c.report(BreakingWire, bv, moved, "enum value %s changed number from %d to %d", bv.Name(), bv.Number(), moved.Number())
+			case !cur.ReservedRanges().Has(bv.Number()):
+				c.report(BreakingWire, bv, cur, "enum value %s (%d) was removed without reserving its number", bv.Name(), bv.Number())
+			case !cur.ReservedNames().Has(bv.Name()):
+				c.report(BreakingWireJSON, bv, cur, "enum value %s (%d) was removed without reserving its name", bv.Name(), bv.Number())
+			default:
+				c.report(BreakingSource, bv, cur, "enum value %s (%d) was removed", bv.Name(), bv.Number())
+			}
+			continue
+		}
+		// JSON encodes enum values by name
+		if cv.Name() != bv.Name() && cur.Values().ByName(bv.Name()) == nil {
+			c.report(BreakingWireJSON, cv, cv, "enum value %d was renamed from %s to %s", bv.Number(), bv.Name(), cv.Name())
+		}
+	}
+
+	for i := 0; i < cur.Values().Len(); i++ {
+		cv := cur.Values().Get(i)
+		if base.ReservedRanges().Has(cv.Number()) {
+			c.report(BreakingWire, cv, cv, "enum value %s uses number %d reserved in the baseline", cv.Name(), cv.Number())
+		}
+		if base.ReservedNames().Has(cv.Name()) {
+			c.report(BreakingWireJSON, cv, cv, "enum value %s uses a name reserved in the baseline", cv.Name())
+		}
+	}
+}
+
+// service compares the RPCs of a service
+func (c *breakingComparison) service(base, cur protoreflect.ServiceDescriptor) {
+	for i := 0; i < base.Methods().Len(); i++ {
+		bm := base.Methods().Get(i)
+		var cm protoreflect.MethodDescriptor
+		if cur != nil {
+			cm = cur.Methods().ByName(bm.Name())
+		}
+		if cm == nil {
+			var at protoreflect.Descriptor
+			if cur != nil {
+				at = cur
+			}
+			c.report(BreakingWire, bm, at, "RPC %s was removed or renamed", bm.FullName())
+			for _, binding := range sortedBindings(httpBindings(bm)) {
+				c.report(BreakingHTTP, bm, at, "HTTP binding %s of RPC %s was removed", binding, bm.Name())
+			}
+			continue
+		}
+		c.method(bm, cm)
+	}
+}
+
+// method compares the signature and HTTP bindings of an RPC
+func (c *breakingComparison) method(bm, cm protoreflect.MethodDescriptor) {
+	if bm.Input().FullName() != cm.Input().FullName() {
+		c.report(BreakingWire, cm, cm, "RPC %s changed request type from %s to %s", cm.Name(), bm.Input().FullName(), cm.Input().FullName())
+	}
+	if bm.Output().FullName() != cm.Output().FullName() {
+		c.report(BreakingWire, cm, cm, "RPC %s changed response type from %s to %s", cm.Name(), bm.Output().FullName(), cm.Output().FullName())
+	}
+	if streamingKind(bm) != streamingKind(cm) {
+		c.report(BreakingWire, cm, cm, "RPC %s changed from %s to %s", cm.Name(), streamingKind(bm), streamingKind(cm))
+	}
+
+	base, cur := httpBindings(bm), httpBindings(cm)
+	for _, binding := range sortedBindings(base) {
+		body, ok := cur[binding]
+		switch {
+		case !ok:
+			c.report(BreakingHTTP, cm, cm, "HTTP binding %s of RPC %s was removed", binding, cm.Name())
+		case body != base[binding]:
+			c.report(BreakingHTTP, cm, cm, "HTTP binding %s of RPC %s changed %s to %s", binding, cm.Name(), base[binding], body)
+		}
+	}
+}
+
+// fieldTypeName names the type of a field: its kind, or the full name of
+// its message or enum
+func fieldTypeName(f protoreflect.FieldDescriptor) string {
+	switch {
+	case f.IsMap():
+		return fmt.Sprintf("map<%s, %s>", fieldTypeName(f.MapKey()), fieldTypeName(f.MapValue()))
+	case f.Message() != nil:
+		return string(f.Message().FullName())
+	case f.Enum() != nil:
+		return string(f.Enum().FullName())
+	}
+	return f.Kind().String()
+}
+
+// wireClass groups the types whose binary encodings are interchangeable
+func wireClass(f protoreflect.FieldDescriptor) string {
+	switch f.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Uint32Kind, protoreflect.Uint64Kind,
+		protoreflect.BoolKind, protoreflect.EnumKind:
+		return "varint"
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return "zigzag"
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind:
+		return "fixed32"
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind:
+		return "fixed64"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return "bytes"
+	}
+	return fieldTypeName(f)
+}
+
+// jsonClass groups the types whose JSON encodings are interchangeable
+func jsonClass(f protoreflect.FieldDescriptor) string {
+	switch f.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "number"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "int64"
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return "float"
+	}
+	return fieldTypeName(f)
+}
+
+// cardinality describes whether a field holds one value, a list or a map
+func cardinality(f protoreflect.FieldDescriptor) string {
+	switch {
+	case f.IsMap():
+		return "map"
+	case f.IsList():
+		return "repeated"
+	}
+	return "singular"
+}
+
+// oneofName returns the name of the real oneof holding f, or ""
+func oneofName(f protoreflect.FieldDescriptor) string {
+	if o := f.ContainingOneof(); o != nil && !o.IsSynthetic() {
+		return string(o.Name())
+	}
+	return ""
+}
+
+// streamingKind names the streaming mode of an RPC
+func streamingKind(m protoreflect.MethodDescriptor) string {
+	switch {
+	case m.IsStreamingClient() && m.IsStreamingServer():
+		return "bidi streaming"
+	case m.IsStreamingClient():
+		return "client streaming"
+	case m.IsStreamingServer():
+		return "server streaming"
+	}
+	return "unary"
+}
+
+// httpRule returns the google.api.http option of an RPC, or nil. Options
+// of compiled protos hold extensions as unknown fields, so they are parsed
+// again against the linked extension types.
+func httpRule(m protoreflect.MethodDescriptor) *annotations.HttpRule {
+	opts, ok := m.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil {
+		return nil
+	}
+	data, err := proto.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+	parsed := &descriptorpb.MethodOptions{}
+	if err := (proto.UnmarshalOptions{Resolver: protoregistry.GlobalTypes}).Unmarshal(data, parsed); err != nil {
+		return nil
+	}
+	if !proto.HasExtension(parsed, annotations.E_Http) {
+		return nil
+	}
+	rule, _ := proto.GetExtension(parsed, annotations.E_Http).(*annotations.HttpRule)
+	return rule
+}
+
+// httpBindings maps "METHOD path" of each binding of an RPC, additional
+// bindings included, to its body and response body settings
+func httpBindings(m protoreflect.MethodDescriptor) map[string]string {
+	bindings := make(map[string]string)
+	rule := httpRule(m)
+	if rule == nil {
+		return bindings
+	}
+	for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		verb, path := httpPattern(r)
+		if verb == "" {
+			continue
+		}
+		bindings[verb+" "+path] = fmt.Sprintf("body %q, response_body %q", r.GetBody(), r.GetResponseBody())
+	}
+	return bindings
+}
+
+// httpPattern returns the HTTP method and path template of a binding
+func httpPattern(r *annotations.HttpRule) (string, string) {
+	switch p := r.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return "GET", p.Get
+	case *annotations.HttpRule_Put:
+		return "PUT", p.Put
+	case *annotations.HttpRule_Post:
+		return "POST", p.Post
+	case *annotations.HttpRule_Delete:
+		return "DELETE", p.Delete
+	case *annotations.HttpRule_Patch:
+		return "PATCH", p.Patch
+	case *annotations.HttpRule_Custom:
+		return strings.ToUpper(p.Custom.GetKind()), p.Custom.GetPath()
+	}
+	return "", ""
+}
+
+// sortedBindings returns the keys of bindings in order
+func sortedBindings(bindings map[string]string) []string {
+	keys := make([]string, 0, len(bindings))
+	for key := range bindings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rangesCover reports whether ranges, possibly split, reserve every number
+// from start to end, exclusive
+func rangesCover(ranges protoreflect.FieldRanges, start, end protoreflect.FieldNumber) bool {
+	for pos := start; pos < end; {
+		advanced := false
+		for i := 0; i < ranges.Len(); i++ {
+			if r := ranges.Get(i); r[0] <= pos && pos < r[1] {
+				pos, advanced = r[1], true
+			}
+		}
+		if !advanced {
+			return false
+		}
+	}
+	return true
+}
+
+// formatRange formats an inclusive range of numbers as written in protos
+func formatRange(start, end protoreflect.FieldNumber) string {
+	if start == end {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d to %d", start, end)
+}
+
+// SaveBaseline saves the current protos as a baseline for CheckBreaking.
+// An empty name uses the current time.
+func (a *App) SaveBaseline(name string) (*Baseline, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = time.Now().Format("20060102-150405")
+	}
+	return ws.saveBaseline(context.Background(), name)
+}
+
+// ListBaselines returns the saved baselines of the workspace, newest first
+func (a *App) ListBaselines() ([]Baseline, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	return ws.baselines()
+}
+
+// CheckBreaking reports the changes of the current protos that break
+// compatibility with a baseline. kind is "snapshot" for a saved baseline
+// (ref names it, empty for the newest), "git" for the protos at git ref,
+// or "file" for a FileDescriptorSet at path ref.
+func (a *App) CheckBreaking(kind, ref string) (*BreakingReport, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	return ws.CheckBreaking(context.Background(), kind, ref)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// breakingV1 is the baseline version of the proto used by the breaking tests
+const breakingV1 = `syntax = "proto3";
+package shop;
+import "google/api/annotations.proto";
+
+message Order {
+  reserved 9;
+  string id = 1;
+  int32 quantity = 2;
+  string note = 3;
+  string customer = 4;
+  repeated string tags = 5;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OPEN = 1;
+}
+
+service OrderService {
+  rpc GetOrder (Order) returns (Order) {
+    option (google.api.http) = { get: "/v1/orders/{id}" };
+  }
+  rpc DeleteOrder (Order) returns (Order);
+  rpc UpdateOrder (Order) returns (Order) {
+    option (google.api.http) = { patch: "/v1/orders/{id}" body: "*" };
+  }
+}
+`
+
+// breakingV2 changes breakingV1 in ways of every category
+const breakingV2 = `syntax = "proto3";
+package shop;
+import "google/api/annotations.proto";
+
+message Order {
+  reserved 3;
+  string id = 1;
+  int64 quantity = 2;
+  bytes customer = 4;
+  string tags = 5;
+  string extra = 9;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OPENED = 1;
+}
+
+service OrderService {
+  rpc GetOrder (Order) returns (Order) {
+    option (google.api.http) = { post: "/v1/orders/{id}" body: "*" };
+  }
+  rpc RemoveOrder (Order) returns (Order);
+  rpc UpdateOrder (Order) returns (Order) {
+    option (google.api.http) = { patch: "/v1/orders/{id}" body: "note" };
+  }
+}
+`
+
+// changeSummaries returns the category and subject of each change
+func changeSummaries(changes []BreakingChange) []string {
+	summaries := make([]string, len(changes))
+	for i, c := range changes {
+		summaries[i] = c.Category + " " + c.Subject
+	}
+	return summaries
+}
+
+// TestCheckBreaking checks the findings against a saved snapshot and that
+// the snapshot also loads as a FileDescriptorSet file
+func TestCheckBreaking(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{"shop.proto": breakingV1})
+
+	baseline, err := app.SaveBaseline("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report, err := app.CheckBreaking(BaselineSnapshot, ""); err != nil || len(report.Changes) != 0 {
+		t.Fatalf("unchanged protos = %+v, %v", report, err)
+	}
+
+	writeFiles(t, ws.PBPath(), map[string]string{"shop.proto": breakingV2})
+	want := []string{
+		"WIRE_JSON shop.Order.quantity",
+		"WIRE_JSON shop.Order.note",
+		"WIRE_JSON shop.Order.customer",
+		"WIRE shop.Order.tags",
+		"WIRE shop.Order.extra",
+		"WIRE shop.Order",
+		"WIRE_JSON shop.STATUS_OPENED",
+		"HTTP shop.OrderService.GetOrder",
+		"WIRE shop.OrderService.DeleteOrder",
+		"HTTP shop.OrderService.UpdateOrder",
+	}
+	for _, tt := range []struct{ kind, ref string }{
+		{BaselineSnapshot, "v1"},
+		{BaselineFile, baseline.Path},
+	} {
+		report, err := app.CheckBreaking(tt.kind, tt.ref)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.kind, err)
+		}
+		if got := changeSummaries(report.Changes); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: changes = %v, want %v", tt.kind, got, want)
+		}
+		if report.Categories[BreakingWire] != 4 || report.Categories[BreakingHTTP] != 2 {
+			t.Errorf("%s: categories = %v", tt.kind, report.Categories)
+		}
+	}
+
+	baselines, err := app.ListBaselines()
+	if err != nil || len(baselines) != 1 || baselines[0].Name != "v1" || baselines[0].Files != 1 {
+		t.Errorf("ListBaselines = %+v, %v", baselines, err)
+	}
+}
+
+// TestCheckBreaking_Git checks the protos of a git ref as the baseline
+func TestCheckBreaking_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	app := NewApp()
+	ws, err := app.OpenWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{"shop.proto": breakingV1})
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "v1"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(ws.PBPath(), "shop.proto"), []byte(breakingV2), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := app.CheckBreaking(BaselineGit, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 10 || report.Baseline != "git HEAD" {
+		t.Errorf("report = %+v", report)
+	}
+
+	if _, err := app.CheckBreaking(BaselineGit, "no-such-ref"); err == nil {
+		t.Error("unknown ref accepted")
+	}
+}
+
+// TestCheckBreaking_GitModules checks that a git ref baseline reads every
+// buf module root from the ref, including imports between them
+func TestCheckBreaking_GitModules(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"buf.yaml":                "version: v2\nmodules:\n  - path: proto\n  - path: api\n",
+		"proto/shop/common.proto": "syntax = \"proto3\";\npackage shop;\nmessage Money { int64 units = 1; }\n",
+		"api/shop/orders.proto":   "syntax = \"proto3\";\npackage shop;\nimport \"shop/common.proto\";\nmessage Order { Money total = 1; string note = 2; }\n",
+		"api/shop/legacy.proto":   "syntax = \"proto3\";\npackage shop;\nmessage Legacy {}\n",
+	})
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "v1"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+
+	// Renaming Money in the working tree breaks the ref's orders.proto
+	// unless common.proto is also read from the ref
+	writeFiles(t, root, map[string]string{
+		"proto/shop/common.proto": "syntax = \"proto3\";\npackage shop;\nmessage Amount { int64 units = 1; }\n",
+		"api/shop/orders.proto":   "syntax = \"proto3\";\npackage shop;\nimport \"shop/common.proto\";\nmessage Order { Amount total = 1; }\n",
+	})
+	if err := os.Remove(filepath.Join(root, "api", "shop", "legacy.proto")); err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewApp()
+	if _, err := app.OpenWorkspace(root); err != nil {
+		t.Fatal(err)
+	}
+	report, err := app.CheckBreaking(BaselineGit, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, summary := range changeSummaries(report.Changes) {
+		got[summary] = true
+	}
+	for _, want := range []string{
+		"SOURCE shop.Legacy",
+		"SOURCE shop.Money",
+		"WIRE shop.Order.note",
+	} {
+		if !got[want] {
+			t.Errorf("missing %s in %v", want, changeSummaries(report.Changes))
+		}
+	}
+}
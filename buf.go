@@ -0,0 +1,439 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// bufModuleFile is the buf module config, relative to the workspace root
+	bufModuleFile = "buf.yaml"
+	// bufWorkFile lists the module directories of a buf v1 workspace
+	bufWorkFile = "buf.work.yaml"
+	// bufGenFile is the buf generation template, relative to the workspace root
+	bufGenFile = "buf.gen.yaml"
+)
+
+// bufVendorDirs are searched, relative to the workspace root, for locally
+// vendored copies of buf deps, laid out as <remote>/<owner>/<repo>,
+// <owner>/<repo> or <repo>
+var bufVendorDirs = []string{"vendor", "third_party"}
+
+// bufPackageVersionPattern matches a versioned last package component such
+// as v1, v1beta1 or v2alpha
+var bufPackageVersionPattern = regexp.MustCompile(`^v\d+((alpha|beta)\d*)?(test\w*)?$`)
+
+// BufConfig is the part of a workspace's buf.yaml and buf.gen.yaml that
+// pb-tool understands. Paths are slash-separated and relative to the
+// workspace root.
+type BufConfig struct {
+	Modules []BufModule     `json:"modules"`
+	Deps    []BufDep        `json:"deps,omitempty"`
+	Plugins []PluginConfig  `json:"plugins,omitempty"`
+	Managed *ManagedOptions `json:"managed,omitempty"`
+}
+
+// BufModule is a proto root of the workspace
+type BufModule struct {
+	Path     string   `json:"path"`
+	Name     string   `json:"name,omitempty"`
+	Excludes []string `json:"excludes,omitempty"`
+}
+
+// BufDep is a buf dependency. Dir is its locally vendored copy, empty when
+// none was found.
+type BufDep struct {
+	Module string `json:"module"`
+	Dir    string `json:"dir,omitempty"`
+}
+
+// ManagedOptions is the go_package part of buf managed mode
+type ManagedOptions struct {
+	GoPackagePrefix string `json:"goPackagePrefix"`
+	// Except lists modules whose go_package is left alone
+	Except []string `json:"except,omitempty"`
+	// Override maps module names to their own go_package prefix
+	Override map[string]string `json:"override,omitempty"`
+}
+
+// yamlStrings is a YAML value that may be a single string or a list
+type yamlStrings []string
+
+// UnmarshalYAML accepts a scalar or a sequence of scalars
+func (s *yamlStrings) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*s = yamlStrings{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// bufYAML is buf.yaml in versions v1beta1, v1 and v2
+type bufYAML struct {
+	Version string   `yaml:"version"`
+	Name    string   `yaml:"name"`
+	Deps    []string `yaml:"deps"`
+	Build   struct {
+		Roots    []string `yaml:"roots"`
+		Excludes []string `yaml:"excludes"`
+	} `yaml:"build"`
+	Modules []struct {
+		Path     string   `yaml:"path"`
+		Name     string   `yaml:"name"`
+		Excludes []string `yaml:"excludes"`
+	} `yaml:"modules"`
+}
+
+// bufWorkYAML is buf.work.yaml
+type bufWorkYAML struct {
+	Directories []string `yaml:"directories"`
+}
+
+// bufGenYAML is buf.gen.yaml in versions v1 and v2
+type bufGenYAML struct {
+	Version string `yaml:"version"`
+	Managed struct {
+		Enabled bool `yaml:"enabled"`
+		// v1
+		GoPackagePrefix struct {
+			Default  string            `yaml:"default"`
+			Except   []string          `yaml:"except"`
+			Override map[string]string `yaml:"override"`
+		} `yaml:"go_package_prefix"`
+		// v2
+		Override []struct {
+			FileOption string `yaml:"file_option"`
+			Module     string `yaml:"module"`
+			Path       string `yaml:"path"`
+			Value      string `yaml:"value"`
+		} `yaml:"override"`
+		Disable []struct {
+			FileOption string `yaml:"file_option"`
+			Module     string `yaml:"module"`
+			Path       string `yaml:"path"`
+		} `yaml:"disable"`
+	} `yaml:"managed"`
+	Plugins []struct {
+		Plugin string      `yaml:"plugin"`
+		Name   string      `yaml:"name"`
+		Remote string      `yaml:"remote"`
+		Local  yamlStrings `yaml:"local"`
+		Path   yamlStrings `yaml:"path"`
+		Out    string      `yaml:"out"`
+		Opt    yamlStrings `yaml:"opt"`
+	} `yaml:"plugins"`
+}
+
+// bufRemotePlugins maps remote buf plugins to the local plugin producing the
+// same output
+var bufRemotePlugins = map[string]string{
+	"buf.build/protocolbuffers/go":       "go",
+	"buf.build/grpc/go":                  "go-grpc",
+	"buf.build/grpc-ecosystem/gateway":   "grpc-gateway",
+	"buf.build/grpc-ecosystem/openapiv2": "openapiv2",
+}
+
+// loadBufConfig reads buf.yaml or buf.work.yaml and buf.gen.yaml from root.
+// It returns nil when the workspace has none of them.
+func loadBufConfig(root string) (*BufConfig, error) {
+	cfg := &BufConfig{}
+	found := false
+
+	var work bufWorkYAML
+	if ok, err := readYAML(filepath.Join(root, bufWorkFile), &work); err != nil {
+		return nil, err
+	} else if ok {
+		found = true
+		for _, dir := range work.Directories {
+			if err := cfg.addModuleConfig(root, path.Clean(dir)); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if _, err := os.Stat(filepath.Join(root, bufModuleFile)); err == nil {
+			found = true
+		}
+		if err := cfg.addModuleConfig(root, "."); err != nil {
+			return nil, err
+		}
+	}
+
+	var gen bufGenYAML
+	if ok, err := readYAML(filepath.Join(root, bufGenFile), &gen); err != nil {
+		return nil, err
+	} else if ok {
+		found = true
+		cfg.addGenConfig(&gen)
+	}
+
+	if !found {
+		return nil, nil
+	}
+	cfg.vendorDeps(root)
+	return cfg, nil
+}
+
+// readYAML decodes the YAML file at path into v, reporting whether it exists
+func readYAML(path string, v interface{}) (bool, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading %s: %w", filepath.Base(path), err)
+	}
+	if err := yaml.Unmarshal(content, v); err != nil {
+		return false, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+	return true, nil
+}
+
+// addModuleConfig adds the modules declared by the buf.yaml in dir, which is
+// relative to root. A directory without buf.yaml is a module of its own.
+func (c *BufConfig) addModuleConfig(root, dir string) error {
+	var mod bufYAML
+	ok, err := readYAML(filepath.Join(root, filepath.FromSlash(dir), bufModuleFile), &mod)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if dir != "." {
+			c.Modules = append(c.Modules, BufModule{Path: dir})
+		}
+		return nil
+	}
+
+	// Excludes of every version are relative to the buf.yaml directory
+	excludes := func(paths []string) []string {
+		var out []string
+		for _, p := range paths {
+			out = append(out, path.Join(dir, p))
+		}
+		return out
+	}
+
+	switch {
+	case mod.Version == "v2":
+		for _, m := range mod.Modules {
+			c.Modules = append(c.Modules, BufModule{
+				Path:     path.Join(dir, m.Path),
+				Name:     m.Name,
+				Excludes: excludes(m.Excludes),
+			})
+		}
+		if len(mod.Modules) == 0 {
+			c.Modules = append(c.Modules, BufModule{Path: dir, Name: mod.Name})
+		}
+	case len(mod.Build.Roots) > 0:
+		// v1beta1 roots share the module's name and excludes
+		for _, r := range mod.Build.Roots {
+			c.Modules = append(c.Modules, BufModule{
+				Path:     path.Join(dir, r),
+				Name:     mod.Name,
+				Excludes: excludes(mod.Build.Excludes),
+			})
+		}
+	default:
+		c.Modules = append(c.Modules, BufModule{
+			Path:     dir,
+			Name:     mod.Name,
+			Excludes: excludes(mod.Build.Excludes),
+		})
+	}
+
+	for _, dep := range mod.Deps {
+		c.addDep(dep)
+	}
+	return nil
+}
+
+// addDep records a dep once, without its :ref suffix
+func (c *BufConfig) addDep(dep string) {
+	if i := strings.LastIndex(dep, ":"); i > strings.LastIndex(dep, "/") {
+		dep = dep[:i]
+	}
+	for _, d := range c.Deps {
+		if d.Module == dep {
+			return
+		}
+	}
+	c.Deps = append(c.Deps, BufDep{Module: dep})
+}
+
+// vendorDeps looks for a locally vendored copy of every dep
+func (c *BufConfig) vendorDeps(root string) {
+	for i, dep := range c.Deps {
+		parts := strings.Split(dep.Module, "/")
+		var candidates []string
+		for n := len(parts); n >= 1; n-- {
+			candidates = append(candidates, strings.Join(parts[len(parts)-n:], "/"))
+		}
+
+		for _, vendor := range bufVendorDirs {
+			for _, candidate := range candidates {
+				dir := path.Join(vendor, candidate)
+				if info, err := os.Stat(filepath.Join(root, filepath.FromSlash(dir))); err == nil && info.IsDir() {
+					c.Deps[i].Dir = dir
+					break
+				}
+			}
+			if c.Deps[i].Dir != "" {
+				break
+			}
+		}
+	}
+}
+
+// addGenConfig converts the plugins and managed mode of buf.gen.yaml
+func (c *BufConfig) addGenConfig(gen *bufGenYAML) {
+	for _, p := range gen.Plugins {
+		plugin := PluginConfig{Out: p.Out, Opt: p.Opt}
+
+		// v1 names remote plugins in the plugin field
+		remote := p.Remote
+		if remote == "" && strings.Contains(p.Plugin, "/") {
+			remote = p.Plugin
+		}
+
+		switch {
+		case remote != "":
+			// Remote plugins run as the matching local plugin
+			module := remote
+			if i := strings.LastIndex(module, ":"); i > strings.LastIndex(module, "/") {
+				module = module[:i]
+			}
+			plugin.Name = bufRemotePlugins[module]
+			if plugin.Name == "" {
+				plugin.Name = path.Base(module)
+			}
+		case len(p.Local) > 0:
+			// Bare protoc-gen-* names may run as a built-in generator
+			local := p.Local[0]
+			if name, ok := strings.CutPrefix(local, "protoc-gen-"); ok && !strings.ContainsAny(name, `/\`) {
+				plugin.Name = name
+			} else {
+				plugin.Name = strings.TrimPrefix(filepath.Base(local), "protoc-gen-")
+				plugin.Path = local
+			}
+		default:
+			plugin.Name = p.Plugin
+			if plugin.Name == "" {
+				plugin.Name = p.Name
+			}
+			if len(p.Path) > 0 {
+				plugin.Path = p.Path[0]
+			}
+		}
+		if plugin.Name != "" {
+			c.Plugins = append(c.Plugins, plugin)
+		}
+	}
+
+	m := gen.Managed
+	if !m.Enabled {
+		return
+	}
+	managed := &ManagedOptions{
+		GoPackagePrefix: m.GoPackagePrefix.Default,
+		Except:          m.GoPackagePrefix.Except,
+		Override:        m.GoPackagePrefix.Override,
+	}
+	for _, o := range m.Override {
+		if o.FileOption != "go_package_prefix" || o.Path != "" {
+			continue
+		}
+		if o.Module == "" {
+			managed.GoPackagePrefix = o.Value
+			continue
+		}
+		if managed.Override == nil {
+			managed.Override = make(map[string]string)
+		}
+		managed.Override[o.Module] = o.Value
+	}
+	for _, d := range m.Disable {
+		if d.Module != "" && d.Path == "" && (d.FileOption == "" || d.FileOption == "go_package" || d.FileOption == "go_package_prefix") {
+			managed.Except = append(managed.Except, d.Module)
+		}
+	}
+	if managed.GoPackagePrefix != "" || len(managed.Override) > 0 {
+		c.Managed = managed
+	}
+}
+
+// excluded reports whether the root-relative slash path rel is excluded by
+// a buf module
+func (c *BufConfig) excluded(rel string) bool {
+	if c == nil {
+		return false
+	}
+	for _, m := range c.Modules {
+		for _, ex := range m.Excludes {
+			if rel == ex || strings.HasPrefix(rel, ex+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// goPackagePrefix returns the managed go_package prefix for files of module
+func (m *ManagedOptions) goPackagePrefix(module string) string {
+	if prefix, ok := m.Override[module]; ok && module != "" {
+		return prefix
+	}
+	for _, except := range m.Except {
+		if except == module && module != "" {
+			return ""
+		}
+	}
+	return m.GoPackagePrefix
+}
+
+// managedGoPackage returns the go_package buf managed mode gives to file:
+// the prefix joined with the file's directory, plus a package name built
+// from the last two package components when the package is versioned
+func managedGoPackage(prefix string, file *descriptorpb.FileDescriptorProto) string {
+	goPackage := path.Join(prefix, path.Dir(file.GetName()))
+	parts := strings.Split(file.GetPackage(), ".")
+	if len(parts) >= 2 && bufPackageVersionPattern.MatchString(parts[len(parts)-1]) {
+		goPackage += ";" + parts[len(parts)-2] + parts[len(parts)-1]
+	}
+	return goPackage
+}
+
+// applyManaged rewrites the go_package of the workspace and vendored dep
+// files of req the way buf managed mode does
+func (w *Workspace) applyManaged(req *pluginpb.CodeGeneratorRequest) {
+	if w.Buf == nil || w.Buf.Managed == nil {
+		return
+	}
+	for _, file := range req.GetProtoFile() {
+		module, ok := w.fileModule(file.GetName())
+		if !ok {
+			continue
+		}
+		prefix := w.Buf.Managed.goPackagePrefix(module)
+		if prefix == "" {
+			continue
+		}
+		if file.Options == nil {
+			file.Options = &descriptorpb.FileOptions{}
+		}
+		file.Options.GoPackage = proto.String(managedGoPackage(prefix, file))
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files below root from slash-separated relative paths
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestLoadBufConfig_V1 checks buf.work.yaml, v1 buf.yaml and v1 buf.gen.yaml parsing
+func TestLoadBufConfig_V1(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"buf.work.yaml":  "version: v1\ndirectories:\n  - proto\n  - api\n",
+		"proto/buf.yaml": "version: v1\nname: buf.build/acme/weather\ndeps:\n  - buf.build/googleapis/googleapis:main\nbuild:\n  excludes:\n    - legacy\n",
+		"buf.gen.yaml": `version: v1
+managed:
+  enabled: true
+  go_package_prefix:
+    default: example.com/gen
+    except:
+      - buf.build/googleapis/googleapis
+    override:
+      buf.build/acme/weather: example.com/weather
+plugins:
+  - plugin: go
+    out: gen
+    opt: paths=source_relative
+  - plugin: buf.build/grpc/go
+    out: gen
+  - remote: buf.build/grpc/go:v1.5.1
+    out: gen
+  - name: validate
+    path: bin/protoc-gen-validate
+    out: gen
+    opt:
+      - lang=go
+      - paths=source_relative
+`,
+		"vendor/googleapis/google/api/http.proto": "",
+	})
+
+	cfg, err := loadBufConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantModules := []BufModule{
+		{Path: "proto", Name: "buf.build/acme/weather", Excludes: []string{"proto/legacy"}},
+		{Path: "api"},
+	}
+	if !reflect.DeepEqual(cfg.Modules, wantModules) {
+		t.Errorf("modules = %+v, want %+v", cfg.Modules, wantModules)
+	}
+	wantDeps := []BufDep{{Module: "buf.build/googleapis/googleapis", Dir: "vendor/googleapis"}}
+	if !reflect.DeepEqual(cfg.Deps, wantDeps) {
+		t.Errorf("deps = %+v, want %+v", cfg.Deps, wantDeps)
+	}
+
+	wantPlugins := []PluginConfig{
+		{Name: "go", Out: "gen", Opt: []string{"paths=source_relative"}},
+		{Name: "go-grpc", Out: "gen"},
+		{Name: "go-grpc", Out: "gen"},
+		{Name: "validate", Path: "bin/protoc-gen-validate", Out: "gen", Opt: []string{"lang=go", "paths=source_relative"}},
+	}
+	if !reflect.DeepEqual(cfg.Plugins, wantPlugins) {
+		t.Errorf("plugins = %+v, want %+v", cfg.Plugins, wantPlugins)
+	}
+
+	m := cfg.Managed
+	if m == nil {
+		t.Fatal("managed mode not loaded")
+	}
+	if got := m.goPackagePrefix("buf.build/acme/weather"); got != "example.com/weather" {
+		t.Errorf("override prefix = %q", got)
+	}
+	if got := m.goPackagePrefix("buf.build/googleapis/googleapis"); got != "" {
+		t.Errorf("excepted module prefix = %q, want none", got)
+	}
+	if got := m.goPackagePrefix(""); got != "example.com/gen" {
+		t.Errorf("default prefix = %q", got)
+	}
+
+	if !cfg.excluded("proto/legacy/old.proto") || cfg.excluded("proto/legacyx.proto") {
+		t.Error("excludes should match whole path components")
+	}
+}
+
+// TestGenerateGRPC_Buf checks that a v2 buf workspace generates with its
+// roots, excludes, vendored deps, plugins and managed go_package prefix
+func TestGenerateGRPC_Buf(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"buf.yaml": "version: v2\nmodules:\n  - path: proto\n    excludes:\n      - proto/internal\ndeps:\n  - buf.build/acme/units\n",
+		"buf.gen.yaml": `version: v2
+managed:
+  enabled: true
+  override:
+    - file_option: go_package_prefix
+      value: example.com/gen
+plugins:
+  - local: protoc-gen-go
+    out: gen
+    opt: paths=source_relative
+`,
+		"proto/internal/skip.proto":               "syntax = \"proto3\";\n",
+		"vendor/buf.build/acme/units/units.proto": "syntax = \"proto3\";\npackage units;\nmessage Celsius { double value = 1; }\n",
+	})
+
+	app := NewApp()
+	ws, err := app.OpenWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.PBPath() != filepath.Join(root, "proto") {
+		t.Errorf("PBPath = %s, want the buf module root", ws.PBPath())
+	}
+
+	content := "syntax = \"proto3\";\npackage acme.weather.v1;\nimport \"units.proto\";\nmessage Forecast { units.Celsius high = 1; }\n"
+	result := app.GenerateGRPC("acme/weather/v1/weather.proto", content, "", ScopeFile)
+	if result.Status != StatusSuccess {
+		t.Fatalf("status = %s: %s", result.Status, result.Summary)
+	}
+
+	generated, err := os.ReadFile(filepath.Join(root, "gen", "acme", "weather", "v1", "weather.pb.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"package weatherv1", `"example.com/gen"`} {
+		if !strings.Contains(string(generated), want) {
+			t.Errorf("weather.pb.go does not contain %s", want)
+		}
+	}
+
+	files, err := ws.ProtoFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"acme/weather/v1/weather.proto"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ProtoFiles = %v, want %v", files, want)
+	}
+}
+
+// TestRootBufModule checks that a buf.yaml at the workspace root does not
+// pull the output, include, vendored and excluded directories into the
+// workspace protos
+func TestRootBufModule(t *testing.T) {
+	root := t.TempDir()
+	proto := "syntax = \"proto3\";\npackage demo;\nmessage A {}\n"
+	writeFiles(t, root, map[string]string{
+		"buf.yaml":                         "version: v2\nmodules:\n  - path: .\n    excludes:\n      - legacy\n",
+		"demo/a.proto":                     proto,
+		"legacy/old.proto":                 proto,
+		"grpc_output/pb/copy.proto":        proto,
+		"include/protobuf/timestamp.proto": proto,
+		".pb-tool/cache/a.proto":           proto,
+		"frontend/node_modules/x.proto":    proto,
+	})
+
+	ws, err := LoadWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.PBPath() != root {
+		t.Fatalf("PBPath = %s, want the workspace root", ws.PBPath())
+	}
+	files, err := ws.ProtoFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"demo/a.proto"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ProtoFiles = %v, want %v", files, want)
+	}
+	tree, err := ws.buildProtoTree(ws.PBPath(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Children) != 1 || tree.Children[0].Name != "demo" {
+		t.Errorf("tree children = %+v, want only demo", tree.Children)
+	}
+}
+
+// TestBufWorkModules checks that the protos of every buf.work.yaml module
+// are listed, read and shown in the tree by their import path
+func TestBufWorkModules(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"buf.work.yaml":      "version: v1\ndirectories:\n  - proto\n  - api\n",
+		"proto/demo/a.proto": "syntax = \"proto3\";\npackage demo;\nmessage A {}\n",
+		"api/demo/b.proto":   "syntax = \"proto3\";\npackage demo;\nimport \"demo/a.proto\";\nmessage B { A a = 1; }\n",
+		"api/other/c.proto":  "syntax = \"proto3\";\npackage other;\nmessage C {}\n",
+		"api/demo/a.proto":   "syntax = \"proto3\";\npackage shadowed;\n",
+	})
+
+	ws, err := LoadWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := ws.ProtoFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"demo/a.proto", "demo/b.proto", "other/c.proto"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("ProtoFiles = %v, want %v", files, want)
+	}
+	if got, want := ws.protoPath("demo/b.proto"), filepath.Join(root, "api", "demo", "b.proto"); got != want {
+		t.Errorf("protoPath(demo/b.proto) = %s, want %s", got, want)
+	}
+	if got, want := ws.protoPath("demo/a.proto"), filepath.Join(root, "proto", "demo", "a.proto"); got != want {
+		t.Errorf("protoPath(demo/a.proto) = %s, want the first root %s", got, want)
+	}
+
+	tree, err := ws.protoTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, dir := range tree.Children {
+		for _, file := range dir.Children {
+			names = append(names, file.Path)
+		}
+	}
+	if want := []string{"demo/a.proto", "demo/b.proto", "other/c.proto"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("tree files = %v, want %v", names, want)
+	}
+
+	dependents, err := ws.Dependents("demo/a.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"demo/b.proto"}; !reflect.DeepEqual(dependents, want) {
+		t.Errorf("Dependents = %v, want %v", dependents, want)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// generateCacheFile holds the generation cache, relative to the workspace root
+var generateCacheFile = filepath.Join(".pb-tool", "generate-cache.json")
+
+// generateCache remembers, per plugin and proto, the inputs of the last
+// generation and the outputs it wrote, so unchanged protos are not
+// regenerated
+type generateCache struct {
+	path    string
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+// cacheEntry is the cached generation of one proto by one plugin. Key
+// hashes the proto's import closure, the plugin version and its options.
+type cacheEntry struct {
+	Key     string         `json:"key"`
+	Outputs []cachedOutput `json:"outputs"`
+}
+
+// cachedOutput is a file written by a cached generation
+type cachedOutput struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// loadGenerateCache reads the cache of the workspace at root. A missing or
+// unreadable cache is treated as empty.
+func loadGenerateCache(root string) *generateCache {
+	cache := &generateCache{
+		path:    filepath.Join(root, generateCacheFile),
+		Entries: make(map[string]cacheEntry),
+	}
+	content, err := os.ReadFile(cache.path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(content, cache); err != nil || cache.Entries == nil {
+		cache.Entries = make(map[string]cacheEntry)
+	}
+	return cache
+}
+
+// save writes the cache, replacing the previous file atomically
+func (c *generateCache) save() error {
+	content, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// cacheEntryID identifies the generation of file by gen
+func cacheEntryID(gen *generator, file string) string {
+	return gen.Name + "\x00" + gen.OutDir + "\x00" + file
+}
+
+// lookup returns the outputs of a cached generation when its key matches and
+// every output is still on disk unchanged
+func (c *generateCache) lookup(id, key, outDir string) ([]cachedOutput, bool) {
+	entry, ok := c.Entries[id]
+	if !ok || entry.Key != key {
+		return nil, false
+	}
+	for _, out := range entry.Outputs {
+		sum, err := fileSHA256(filepath.Join(outDir, filepath.FromSlash(out.Name)))
+		if err != nil || sum != out.SHA256 {
+			return nil, false
+		}
+	}
+	return entry.Outputs, true
+}
+
+// store records the outputs of a generation
+func (c *generateCache) store(id, key string, outputs []cachedOutput) {
+	if outputs == nil {
+		outputs = []cachedOutput{}
+	}
+	c.Entries[id] = cacheEntry{Key: key, Outputs: outputs}
+}
+
+// fileSHA256 returns the hex SHA-256 of a file's content
+func fileSHA256(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// pluginVersion identifies the build of a generator: the protobuf module
+// version for built-in generators, the size and modification time of the
+// executable otherwise
+func pluginVersion(gen *generator) string {
+	if gen.builtin != nil {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, dep := range info.Deps {
+				if dep.Path == "google.golang.org/protobuf" {
+					return "builtin " + dep.Version
+				}
+			}
+		}
+		return "builtin"
+	}
+
+	info, err := os.Stat(gen.Path)
+	if err != nil {
+		return gen.Path
+	}
+	return fmt.Sprintf("%s %d %d", gen.Path, info.Size(), info.ModTime().UnixNano())
+}
+
+// generationKeys returns the cache key of every file to generate in req,
+// hashing the plugin version, the options and the descriptors of the
+// file's transitive import closure as the plugin will see them
+func generationKeys(gen *generator, req *pluginpb.CodeGeneratorRequest) map[string]string {
+	byName := make(map[string]*descriptorpb.FileDescriptorProto, len(req.GetProtoFile()))
+	for _, fd := range req.GetProtoFile() {
+		byName[fd.GetName()] = fd
+	}
+
+	version := pluginVersion(gen)
+	marshal := proto.MarshalOptions{Deterministic: true}
+	keys := make(map[string]string, len(req.GetFileToGenerate()))
+	for _, file := range req.GetFileToGenerate() {
+		// Collect the import closure of file
+		closure := make(map[string]bool)
+		var visit func(name string)
+		visit = func(name string) {
+			if closure[name] {
+				return
+			}
+			closure[name] = true
+			for _, dep := range byName[name].GetDependency() {
+				visit(dep)
+			}
+		}
+		visit(file)
+
+		names := make([]string, 0, len(closure))
+		for name := range closure {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		h := sha256.New()
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", version, gen.Parameter, file)
+		for _, name := range names {
+			content, _ := marshal.Marshal(byName[name])
+			fmt.Fprintf(h, "%s\x00%d\x00", name, len(content))
+			h.Write(content)
+		}
+		keys[file] = hex.EncodeToString(h.Sum(nil))
+	}
+	return keys
+}
+
+// ClearGenerationCache forgets the cached generations of the active
+// workspace, so the next run regenerates every proto
+func (a *App) ClearGenerationCache() error {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(ws.Root, generateCacheFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// internal_gengo has no compatibility promise; go.mod pins protobuf-go
+	gengo "google.golang.org/protobuf/cmd/protoc-gen-go/internal_gengo"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// newCodeGeneratorRequest builds the request a protoc plugin would receive
+// from protoc for generating files with the given parameter
+func newCodeGeneratorRequest(files []protoreflect.FileDescriptor, parameter string) *pluginpb.CodeGeneratorRequest {
+	req := &pluginpb.CodeGeneratorRequest{}
+	if parameter != "" {
+		req.Parameter = proto.String(parameter)
+	}
+	for _, fd := range files {
+		req.FileToGenerate = append(req.FileToGenerate, fd.Path())
+	}
+	for _, fd := range transitiveFiles(files) {
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(fd))
+	}
+	return req
+}
+
+// generateGoBuiltin runs protoc-gen-go in process
+func generateGoBuiltin(req *pluginpb.CodeGeneratorRequest) (*pluginpb.CodeGeneratorResponse, error) {
+	var flags flag.FlagSet
+	gen, err := protogen.Options{ParamFunc: flags.Set}.New(req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range gen.Files {
+		if f.Generate {
+			gengo.GenerateFile(gen, f)
+		}
+	}
+	gen.SupportedFeatures = gengo.SupportedFeatures
+	gen.SupportedEditionsMinimum = gengo.SupportedEditionsMinimum
+	gen.SupportedEditionsMaximum = gengo.SupportedEditionsMaximum
+	return gen.Response(), nil
+}
+
+// runPluginBinary runs a protoc plugin executable in dir directly, speaking
+// the protoc plugin protocol over stdin and stdout. Every stderr line is
+// passed to output as it arrives; stdout carries the response, so it is
+// only passed on when it is not one.
+func runPluginBinary(ctx context.Context, dir, path string, req *pluginpb.CodeGeneratorRequest, output func(stream, line string)) (*pluginpb.CodeGeneratorResponse, error) {
+	input, err := proto.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling plugin request: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	stderrLines := &lineWriter{emit: func(line string) { output("stderr", line) }}
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Dir = dir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = io.MultiWriter(&stderr, stderrLines)
+	// Don't wait on children that inherited the pipes after a cancel
+	cmd.WaitDelay = 2 * time.Second
+
+	err = cmd.Run()
+	stderrLines.Flush()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v: %s", filepath.Base(path), err, strings.TrimSpace(stderr.String()))
+	}
+
+	resp := &pluginpb.CodeGeneratorResponse{}
+	if err := proto.Unmarshal(stdout.Bytes(), resp); err != nil {
+		stdoutLines := &lineWriter{emit: func(line string) { output("stdout", line) }}
+		stdoutLines.Write(stdout.Bytes())
+		stdoutLines.Flush()
+		return nil, fmt.Errorf("%s: invalid plugin response: %w", filepath.Base(path), err)
+	}
+	return resp, nil
+}
+
+// lineWriter passes every complete line written to it to emit
+type lineWriter struct {
+	emit func(line string)
+	buf  []byte
+}
+
+// Write emits the complete lines of p and keeps the rest for later
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(strings.TrimRight(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush emits a final line without a newline
+func (w *lineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.emit(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// writeGeneratorResponse writes the files of a plugin response below outDir
+// and returns their paths relative to it
+func writeGeneratorResponse(outDir string, resp *pluginpb.CodeGeneratorResponse) ([]string, error) {
+	if resp.GetError() != "" {
+		return nil, fmt.Errorf("%s", resp.GetError())
+	}
+
+	var written []string
+	for _, file := range resp.GetFile() {
+		if file.GetInsertionPoint() != "" {
+			return written, fmt.Errorf("%s: insertion points are not supported", file.GetName())
+		}
+
+		name := filepath.Clean(filepath.FromSlash(file.GetName()))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return written, fmt.Errorf("plugin output %s escapes the output directory", file.GetName())
+		}
+
+		path := filepath.Join(outDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, []byte(file.GetContent()), 0644); err != nil {
+			return written, err
+		}
+		written = append(written, filepath.ToSlash(name))
+	}
+	return written, nil
+}
+
+// findPlugin locates a protoc plugin executable in GOBIN, GOPATH/bin or PATH
+func findPlugin(name string) (string, error) {
+	var dirs []string
+	if gobin := os.Getenv("GOBIN"); gobin != "" {
+		dirs = append(dirs, gobin)
+	}
+	if gopath := os.Getenv("GOPATH"); gopath != "" {
+		for _, p := range filepath.SplitList(gopath) {
+			dirs = append(dirs, filepath.Join(p, "bin"))
+		}
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, "go", "bin"))
+	}
+
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return exec.LookPath(name)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/reporter"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	// Registers google/api/annotations.proto and google/api/http.proto so
+	// protos importing them link without a googleapis checkout
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+)
+
+// wellKnownPrefix is the import prefix of the protobuf well-known types
+const wellKnownPrefix = "google/protobuf/"
+
+// sourceRoots returns the pb directory followed by the other buf module roots
+func (w *Workspace) sourceRoots() []string {
+	roots := []string{w.PBPath()}
+	if w.Buf == nil {
+		return roots
+	}
+	for _, m := range w.Buf.Modules {
+		dir := w.path(filepath.FromSlash(m.Path))
+		if dir != roots[0] {
+			roots = append(roots, dir)
+		}
+	}
+	return roots
+}
+
+// holdsSourceRoot reports whether dir is or contains a source root
+func (w *Workspace) holdsSourceRoot(dir string) bool {
+	for _, root := range w.sourceRoots() {
+		if isWithin(root, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// importPaths returns the source roots, the include directory and the
+// vendored buf deps, in lookup order
+func (w *Workspace) importPaths() []string {
+	paths := append(w.sourceRoots(), w.IncludePath())
+	if w.Buf != nil {
+		for _, dep := range w.Buf.Deps {
+			if dep.Dir != "" {
+				paths = append(paths, w.path(filepath.FromSlash(dep.Dir)))
+			}
+		}
+	}
+	return paths
+}
+
+// fileModule finds the import path name in the source roots or vendored
+// deps and returns the buf module it belongs to. ok is false for files of
+// the include directory or linked into pb-tool.
+func (w *Workspace) fileModule(name string) (module string, ok bool) {
+	rel := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	exists := func(dir string) bool {
+		_, err := os.Stat(filepath.Join(dir, rel))
+		return err == nil
+	}
+
+	if exists(w.PBPath()) {
+		if w.Buf != nil {
+			for _, m := range w.Buf.Modules {
+				if w.path(filepath.FromSlash(m.Path)) == w.PBPath() {
+					return m.Name, true
+				}
+			}
+		}
+		return "", true
+	}
+	if w.Buf == nil {
+		return "", false
+	}
+	for _, m := range w.Buf.Modules {
+		if exists(w.path(filepath.FromSlash(m.Path))) {
+			return m.Name, true
+		}
+	}
+	for _, dep := range w.Buf.Deps {
+		if dep.Dir != "" && exists(w.path(filepath.FromSlash(dep.Dir))) {
+			return dep.Module, true
+		}
+	}
+	return "", false
+}
+
+// resolver returns the import resolver of the workspace. Imports are looked
+// up in the pb directory and other buf module roots, the include directory,
+// the vendored buf deps, then the bundled include/protobuf well-known types,
+// and finally the descriptors linked into pb-tool itself. overlay maps
+// import paths to unsaved content that shadows the file in any source root.
+func (w *Workspace) resolver(overlay map[string]string) protocompile.Resolver {
+	return protocompile.CompositeResolver{
+		protocompile.ResolverFunc(func(path string) (protocompile.SearchResult, error) {
+			if content, ok := overlay[path]; ok {
+				return protocompile.SearchResult{Source: strings.NewReader(content)}, nil
+			}
+			return protocompile.SearchResult{}, os.ErrNotExist
+		}),
+		&protocompile.SourceResolver{ImportPaths: w.importPaths()},
+		protocompile.ResolverFunc(w.findBundledWellKnown),
+		protocompile.ResolverFunc(findLinkedFile),
+	}
+}
+
+// findBundledWellKnown resolves google/protobuf/*.proto from the workspace
+// include/protobuf directory, which stores them without the google/ prefix
+func (w *Workspace) findBundledWellKnown(path string) (protocompile.SearchResult, error) {
+	if !strings.HasPrefix(path, wellKnownPrefix) {
+		return protocompile.SearchResult{}, os.ErrNotExist
+	}
+
+	name := filepath.FromSlash(strings.TrimPrefix(path, wellKnownPrefix))
+	f, err := os.Open(filepath.Join(w.IncludePath(), "protobuf", name))
+	if err != nil {
+		return protocompile.SearchResult{}, err
+	}
+	return protocompile.SearchResult{Source: f}, nil
+}
+
+// findLinkedFile resolves imports from descriptors compiled into pb-tool,
+// such as the well-known types and google/api annotations. They are handed
+// over as descriptor protos so their own imports go through the workspace
+// resolver and link against the same well-known types as the workspace.
+func findLinkedFile(path string) (protocompile.SearchResult, error) {
+	fd, err := protoregistry.GlobalFiles.FindFileByPath(path)
+	if err != nil {
+		return protocompile.SearchResult{}, err
+	}
+	return protocompile.SearchResult{Proto: protodesc.ToFileDescriptorProto(fd)}, nil
+}
+
+// Compile parses and links the given protos, named by their slash-separated
+// path relative to the pb directory
+func (w *Workspace) Compile(ctx context.Context, files []string) (linker.Files, error) {
+	return w.compile(ctx, files, nil, nil)
+}
+
+// compile is Compile with unsaved file content and a custom reporter.
+// Each file is linked with its own imports only, in a compilation of its
+// own: protos that are never imported together may define the same
+// symbols, as the baseline example.proto and example_with_routes.proto do,
+// and still compile. With a reporter every file is compiled and the first
+// error returned; without one compilation stops at the first error.
+func (w *Workspace) compile(ctx context.Context, files []string, overlay map[string]string, rep reporter.Reporter) (linker.Files, error) {
+	compiler := protocompile.Compiler{
+		Resolver:       w.resolver(overlay),
+		Reporter:       rep,
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+
+	var compiled linker.Files
+	var firstErr error
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if seen[file] {
+			continue
+		}
+		seen[file] = true
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err := compiler.Compile(ctx, file)
+		if err != nil {
+			if rep == nil || ctx.Err() != nil {
+				return nil, err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		compiled = append(compiled, result...)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return compiled, nil
+}
+
+// CompileAll compiles every proto of the workspace
+func (w *Workspace) CompileAll(ctx context.Context) (linker.Files, error) {
+	files, err := w.ProtoFiles()
+	if err != nil {
+		return nil, fmt.Errorf("finding proto files: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no proto files found in %s", w.PBPath())
+	}
+	return w.Compile(ctx, files)
+}
+
+// transitiveFiles returns files and all of their imports, dependencies first
+func transitiveFiles(files []protoreflect.FileDescriptor) []protoreflect.FileDescriptor {
+	var ordered []protoreflect.FileDescriptor
+	seen := make(map[string]bool)
+
+	var visit func(fd protoreflect.FileDescriptor)
+	visit = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			visit(imports.Get(i).FileDescriptor)
+		}
+		ordered = append(ordered, fd)
+	}
+
+	for _, fd := range files {
+		visit(fd)
+	}
+	return ordered
+}
+
+// linkedDescriptors returns the linker results as plain file descriptors
+func linkedDescriptors(files linker.Files) []protoreflect.FileDescriptor {
+	fds := make([]protoreflect.FileDescriptor, len(files))
+	for i, f := range files {
+		fds[i] = f
+	}
+	return fds
+}
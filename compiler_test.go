@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCompile compiles the bundled example protos without protoc
+func TestCompile(t *testing.T) {
+	ws, err := LoadWorkspace(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compiled, err := ws.Compile(context.Background(), []string{"example.proto"})
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	example := compiled.FindFileByPath("example.proto")
+	if example == nil {
+		t.Fatal("example.proto was not compiled")
+	}
+	if got := example.Services().ByName("ExampleService").Methods().Len(); got != 5 {
+		t.Errorf("ExampleService methods = %d, want 5", got)
+	}
+
+	dependents, err := ws.Dependents("custom_options.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dependents) != 1 || dependents[0] != "example.proto" {
+		t.Errorf("dependents of custom_options.proto = %v, want [example.proto]", dependents)
+	}
+}
+
+// TestGenerateGoBuiltin generates Go code in process for a small proto
+func TestGenerateGoBuiltin(t *testing.T) {
+	root := t.TempDir()
+	ws, err := LoadWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(ws.PBPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	content := `syntax = "proto3";
+package demo;
+option go_package = "example.com/demo";
+import "google/protobuf/timestamp.proto";
+message Event {
+  string id = 1;
+  google.protobuf.Timestamp at = 2;
+}
+`
+	if err := os.WriteFile(filepath.Join(ws.PBPath(), "demo.proto"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiled, err := ws.CompileAll(context.Background())
+	if err != nil {
+		t.Fatalf("CompileAll: %v", err)
+	}
+
+	resp, err := generateGoBuiltin(newCodeGeneratorRequest(linkedDescriptors(compiled), "paths=source_relative"))
+	if err != nil {
+		t.Fatalf("generateGoBuiltin: %v", err)
+	}
+	written, err := writeGeneratorResponse(ws.OutputPath(), resp)
+	if err != nil {
+		t.Fatalf("writeGeneratorResponse: %v", err)
+	}
+	if len(written) != 1 || written[0] != "demo.pb.go" {
+		t.Errorf("written = %v, want [demo.pb.go]", written)
+	}
+}
+
+// TestCompileAll_RepoPB checks that the workspace-wide features work on the
+// repository's own pb directory, whose example.proto and
+// example_with_routes.proto define the same symbols
+func TestCompileAll_RepoPB(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := filepath.Glob(filepath.Join("pb", "*.proto"))
+	if err != nil || len(names) < 3 {
+		t.Fatalf("pb protos = %v, %v", names, err)
+	}
+	for _, name := range names {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, ws.PBPath(), map[string]string{filepath.Base(name): string(content)})
+	}
+
+	compiled, err := ws.CompileAll(context.Background())
+	if err != nil {
+		t.Fatalf("CompileAll: %v", err)
+	}
+	if len(compiled) != len(names) {
+		t.Errorf("compiled %d files, want %d", len(compiled), len(names))
+	}
+	if diags, compiled := ws.Validate(context.Background(), []string{"example.proto", "example_with_routes.proto"}, nil); compiled == nil {
+		t.Errorf("Validate: %v", diags)
+	}
+
+	if _, err := app.DescribeWorkspace(); err != nil {
+		t.Errorf("DescribeWorkspace: %v", err)
+	}
+	if _, err := app.SaveBaseline("repo"); err != nil {
+		t.Errorf("SaveBaseline: %v", err)
+	}
+	if report, err := app.CheckBreaking(BaselineSnapshot, "repo"); err != nil || len(report.Changes) != 0 {
+		t.Errorf("CheckBreaking = %+v, %v; want no changes", report, err)
+	}
+	if _, err := app.LintWorkspace(); err != nil {
+		t.Errorf("LintWorkspace: %v", err)
+	}
+	if _, err := app.workspaceTypes(context.Background()); err != nil {
+		t.Errorf("workspaceTypes: %v", err)
+	}
+}
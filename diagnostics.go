@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Diagnostic severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic codes
+const (
+	CodeSyntax          = "SYNTAX"
+	CodeNoSyntax        = "NO_SYNTAX"
+	CodeUnusedImport    = "UNUSED_IMPORT"
+	CodeImportNotFound  = "IMPORT_NOT_FOUND"
+	CodeDuplicateSymbol = "DUPLICATE_SYMBOL"
+	CodeUnresolved      = "UNRESOLVED"
+	CodeCompile         = "COMPILE"
+	CodeProtoc          = "PROTOC"
+	CodeOutput          = "OUTPUT"
+)
+
+// Diagnostic is a compiler message pointing at a location in a proto file.
+// Line and column are one-based; zero means the position is unknown.
+// EndLine and EndColumn, when set, mark the exclusive end of the range.
+type Diagnostic struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	Code      string `json:"code"`
+}
+
+// String formats the diagnostic the way protoc does
+func (d Diagnostic) String() string {
+	prefix := d.File
+	if d.Line > 0 {
+		prefix = fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+	}
+	if d.Severity == SeverityWarning {
+		return fmt.Sprintf("%s: warning: %s", prefix, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", prefix, d.Message)
+}
+
+// hasErrors reports whether any diagnostic is an error
+func hasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// newDiagnostic converts a protocompile error to a Diagnostic
+func newDiagnostic(err reporter.ErrorWithPos, severity string) Diagnostic {
+	start, end := err.Start(), err.End()
+	d := Diagnostic{
+		File:     start.Filename,
+		Line:     start.Line,
+		Column:   start.Col,
+		Severity: severity,
+		Message:  err.Unwrap().Error(),
+		Code:     diagnosticCode(err.Unwrap()),
+	}
+	if end.Line > 0 && (end.Line != start.Line || end.Col != start.Col) {
+		d.EndLine = end.Line
+		d.EndColumn = end.Col
+	}
+	return d
+}
+
+// diagnosticCode classifies a protocompile error
+func diagnosticCode(err error) string {
+	var unused linker.ErrorUnusedImport
+	switch {
+	case errors.As(err, &unused):
+		return CodeUnusedImport
+	case errors.Is(err, parser.ErrNoSyntax):
+		return CodeNoSyntax
+	case errors.Is(err, os.ErrNotExist):
+		return CodeImportNotFound
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.HasPrefix(msg, "syntax error"):
+		return CodeSyntax
+	case strings.Contains(msg, "already defined"):
+		return CodeDuplicateSymbol
+	case strings.Contains(msg, "not found"), strings.Contains(msg, "unknown"):
+		return CodeUnresolved
+	}
+	return CodeCompile
+}
+
+// Validate compiles files with the built-in compiler and returns every error
+// and warning found, along with the linked files when compilation succeeded.
+// overlay maps pb-relative paths to unsaved content.
+func (w *Workspace) Validate(ctx context.Context, files []string, overlay map[string]string) ([]Diagnostic, linker.Files) {
+	// Files are compiled in parallel, so reports may arrive concurrently
+	var mu sync.Mutex
+	var diags []Diagnostic
+	rep := reporter.NewReporter(
+		func(err reporter.ErrorWithPos) error {
+			mu.Lock()
+			defer mu.Unlock()
+			diags = append(diags, newDiagnostic(err, SeverityError))
+			return nil
+		},
+		func(err reporter.ErrorWithPos) {
+			mu.Lock()
+			defer mu.Unlock()
+			diags = append(diags, newDiagnostic(err, SeverityWarning))
+		},
+	)
+
+	compiled, err := w.compile(ctx, files, overlay, rep)
+	diags = uniqueDiagnostics(diags)
+	if err != nil && !hasErrors(diags) {
+		// Errors without a source position, such as a missing input file
+		file := ""
+		if len(files) == 1 {
+			file = files[0]
+		}
+		code := CodeCompile
+		if errors.Is(err, os.ErrNotExist) {
+			code = CodeImportNotFound
+		}
+		diags = append(diags, Diagnostic{File: file, Severity: SeverityError, Message: err.Error(), Code: code})
+	}
+	if err != nil {
+		return diags, nil
+	}
+	return diags, compiled
+}
+
+// uniqueDiagnostics drops repeated diagnostics, reported again for an
+// import shared by several of the files compiled
+func uniqueDiagnostics(diags []Diagnostic) []Diagnostic {
+	seen := make(map[Diagnostic]bool, len(diags))
+	unique := diags[:0]
+	for _, d := range diags {
+		if !seen[d] {
+			seen[d] = true
+			unique = append(unique, d)
+		}
+	}
+	return unique
+}
+
+// protocDiagnosticPattern matches protoc's "file:line:col: message" output
+var protocDiagnosticPattern = regexp.MustCompile(`^(.+?):(\d+):(\d+): (.*)$`)
+
+// parseProtocOutput converts protoc's stderr into diagnostics
+func parseProtocOutput(output string) []Diagnostic {
+	var diags []Diagnostic
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "[libprotobuf") {
+			continue
+		}
+
+		d := Diagnostic{Severity: SeverityError, Code: CodeProtoc}
+		if m := protocDiagnosticPattern.FindStringSubmatch(line); m != nil {
+			d.File = m[1]
+			d.Line, _ = strconv.Atoi(m[2])
+			d.Column, _ = strconv.Atoi(m[3])
+			d.Message = m[4]
+		} else if i := strings.Index(line, ": "); i > 0 && strings.HasSuffix(line[:i], ".proto") {
+			d.File = line[:i]
+			d.Message = line[i+2:]
+		} else {
+			d.Message = line
+		}
+
+		if rest, ok := strings.CutPrefix(d.Message, "warning: "); ok {
+			d.Severity = SeverityWarning
+			d.Message = rest
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// validateWithProtoc runs protoc on file and parses its diagnostics. Unsaved
+// content is written to a temporary directory that shadows the pb directory.
+// Imports resolve as in the built-in compiler: from the workspace import
+// paths, the bundled well-known types and the descriptors linked into
+// pb-tool.
+func (w *Workspace) validateWithProtoc(ctx context.Context, file string, overlay map[string]string) ([]Diagnostic, error) {
+	protoc, err := exec.LookPath(w.Config.Protoc)
+	if err != nil {
+		return nil, fmt.Errorf("protoc not found: %w", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "pb-tool-validate-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	overlayDir := filepath.Join(tempDir, "overlay")
+	if err := os.MkdirAll(overlayDir, 0755); err != nil {
+		return nil, err
+	}
+	for name, content := range overlay {
+		path := filepath.Join(overlayDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	includeDir, err := w.bundledIncludeRoot(tempDir)
+	if err != nil {
+		return nil, err
+	}
+	linkedSet, err := writeLinkedDescriptorSet(tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"--proto_path=" + overlayDir}
+	for _, dir := range w.importPaths() {
+		args = append(args, "--proto_path="+dir)
+	}
+	args = append(args,
+		"--proto_path="+includeDir,
+		"--descriptor_set_in="+linkedSet,
+		"--descriptor_set_out="+os.DevNull,
+		file,
+	)
+	output, err := exec.CommandContext(ctx, protoc, args...).CombinedOutput()
+	diags := parseProtocOutput(string(output))
+	if err != nil && !hasErrors(diags) {
+		return nil, fmt.Errorf("running protoc: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return diags, nil
+}
+
+// bundledIncludeRoot lays the well-known types of include/protobuf out
+// under google/protobuf in dir, as protoc expects them, and returns the
+// include root holding google/
+func (w *Workspace) bundledIncludeRoot(dir string) (string, error) {
+	root := filepath.Join(dir, "include")
+	bundled := filepath.Join(w.IncludePath(), "protobuf")
+	target := filepath.Join(root, filepath.FromSlash(wellKnownPrefix))
+	err := filepath.WalkDir(bundled, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == bundled {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".proto" {
+			return nil
+		}
+		rel, err := filepath.Rel(bundled, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out := filepath.Join(target, rel)
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(out, content, 0644)
+	})
+	if err != nil {
+		return "", fmt.Errorf("preparing the bundled well-known types: %w", err)
+	}
+	return root, nil
+}
+
+// writeLinkedDescriptorSet writes the descriptors linked into pb-tool, such
+// as google/api/annotations.proto, as a descriptor set in dir. protoc only
+// falls back to it for imports missing from its proto paths.
+func writeLinkedDescriptorSet(dir string) (string, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	protoregistry.GlobalFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+		return true
+	})
+	sort.Slice(set.File, func(i, j int) bool { return set.File[i].GetName() < set.File[j].GetName() })
+	content, err := proto.Marshal(set)
+	if err != nil {
+		return "", fmt.Errorf("encoding the linked descriptors: %w", err)
+	}
+	path := filepath.Join(dir, "linked.pb")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ValidatePB compiles content as the proto at filename, without saving it,
+// and returns the diagnostics for the editor. The workspace protoc is used
+// when configured, otherwise the built-in compiler.
+func (a *App) ValidatePB(filename, content string) ([]Diagnostic, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ws.resolveProtoFile(filename); err != nil {
+		return nil, err
+	}
+
+	filename = cleanProtoPath(filename)
+	overlay := map[string]string{filename: content}
+	var diags []Diagnostic
+	if ws.Config.Protoc != "" {
+		diags, err = ws.validateWithProtoc(context.Background(), filename, overlay)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		diags, _ = ws.Validate(context.Background(), []string{filename}, overlay)
+	}
+
+	// Compiler findings that are lint rules follow the workspace lint
+	// config, as they do when generating
+	diags = ws.applyLintConfig(diags)
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	return diags, nil
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestParseProtocOutput checks parsing of protoc error and warning lines
+func TestParseProtocOutput(t *testing.T) {
+	output := `example.proto:12:3: Expected ";".
+example.proto:4:1: warning: Import google/api/annotations.proto is unused.
+missing.proto: File not found.
+[libprotobuf WARNING google/protobuf/compiler/parser.cc:650] No syntax specified.
+`
+	want := []Diagnostic{
+		{File: "example.proto", Line: 12, Column: 3, Severity: SeverityError, Message: `Expected ";".`, Code: CodeProtoc},
+		{File: "example.proto", Line: 4, Column: 1, Severity: SeverityWarning, Message: "Import google/api/annotations.proto is unused.", Code: CodeProtoc},
+		{File: "missing.proto", Severity: SeverityError, Message: "File not found.", Code: CodeProtoc},
+	}
+
+	got := parseProtocOutput(output)
+	if len(got) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("diagnostic %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestValidate checks positions and codes reported by the built-in compiler
+func TestValidate(t *testing.T) {
+	ws, err := LoadWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		wantCode string
+		wantLine int
+		wantCol  int
+		wantSev  string
+	}{
+		{"syntax error", "syntax = \"proto3\";\nmessage A {\n  string id = 1\n}\n", CodeSyntax, 4, 1, SeverityError},
+		{"unknown type", "syntax = \"proto3\";\nmessage A {\n  Missing m = 1;\n}\n", CodeUnresolved, 3, 3, SeverityError},
+		{"unused import", "syntax = \"proto3\";\nimport \"google/protobuf/empty.proto\";\nmessage A {}\n", CodeUnusedImport, 2, 1, SeverityWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags, _ := ws.Validate(context.Background(), []string{"a.proto"}, map[string]string{"a.proto": tt.content})
+			if len(diags) != 1 {
+				t.Fatalf("got %d diagnostics, want 1: %+v", len(diags), diags)
+			}
+			d := diags[0]
+			if d.Code != tt.wantCode || d.Line != tt.wantLine || d.Column != tt.wantCol || d.Severity != tt.wantSev {
+				t.Errorf("diagnostic = %+v, want %s at %d:%d (%s)", d, tt.wantCode, tt.wantLine, tt.wantCol, tt.wantSev)
+			}
+			if d.File != "a.proto" {
+				t.Errorf("file = %s, want a.proto", d.File)
+			}
+		})
+	}
+}
+
+// TestValidatePB checks that the editor path is normalized to find the
+// unsaved content, and that compiler lint findings follow the lint config
+func TestValidatePB(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{"sub/a.proto": "not a proto on disk"})
+	unused := "syntax = \"proto3\";\nimport \"google/protobuf/empty.proto\";\nmessage A {}\n"
+
+	diags, err := app.ValidatePB("./sub//a.proto", unused)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Code != CodeUnusedImport || diags[0].File != "sub/a.proto" {
+		t.Fatalf("diagnostics = %+v, want the unused import of the editor content", diags)
+	}
+
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"lint": map[string]string{LintUnusedImport: SeverityError}}); err != nil {
+		t.Fatal(err)
+	}
+	if diags, err = app.ValidatePB("sub/a.proto", unused); err != nil || len(diags) != 1 || diags[0].Severity != SeverityError {
+		t.Errorf("diagnostics = %+v, %v; want the unused import as an error", diags, err)
+	}
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"lint": map[string]string{LintUnusedImport: LintOff}}); err != nil {
+		t.Fatal(err)
+	}
+	if diags, err = app.ValidatePB("sub/a.proto", unused); err != nil || len(diags) != 0 {
+		t.Errorf("diagnostics = %+v, %v; want none with the rule off", diags, err)
+	}
+}
+
+// fakeProtoc resolves the imports of the file it is given like protoc does,
+// in the proto paths and then the descriptor set, and reports those it
+// cannot find
+const fakeProtoc = `#!/bin/sh
+paths=""
+set_in=""
+for arg in "$@"; do
+  case "$arg" in
+    --proto_path=*) paths="$paths ${arg#--proto_path=}" ;;
+    --descriptor_set_in=*) set_in="${arg#--descriptor_set_in=}" ;;
+    --*) ;;
+    *) file="$arg" ;;
+  esac
+done
+find_proto() {
+  for dir in $paths; do
+    if [ -f "$dir/$1" ]; then echo "$dir/$1"; return 0; fi
+  done
+  return 1
+}
+source=$(find_proto "$file") || { echo "$file: File not found."; exit 1; }
+status=0
+line=0
+while IFS= read -r text; do
+  line=$((line + 1))
+  import=$(echo "$text" | sed -n 's/^import "\(.*\)";$/\1/p')
+  [ -z "$import" ] && continue
+  find_proto "$import" >/dev/null && continue
+  [ -n "$set_in" ] && grep -q "$import" "$set_in" && continue
+  echo "$file:$line:1: Import \"$import\" was not found or had errors."
+  status=1
+done < "$source"
+exit $status
+`
+
+// TestValidatePB_Protoc checks that protoc resolves imports as the built-in
+// compiler does: the well-known types, the linked google/api protos and the
+// workspace protos
+func TestValidatePB_Protoc(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake protoc is a shell script")
+	}
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.Root, map[string]string{
+		"include/protobuf/timestamp.proto": "syntax = \"proto3\";\npackage google.protobuf;\nmessage Timestamp {\n  int64 seconds = 1;\n  int32 nanos = 2;\n}\n",
+		"pb/common/ids.proto":              "syntax = \"proto3\";\npackage common;\nmessage Id {\n  string value = 1;\n}\n",
+	})
+	protoc := filepath.Join(ws.Root, "protoc")
+	if err := os.WriteFile(protoc, []byte(fakeProtoc), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"protoc": protoc}); err != nil {
+		t.Fatal(err)
+	}
+
+	content := `syntax = "proto3";
+package demo;
+import "google/protobuf/timestamp.proto";
+import "google/api/annotations.proto";
+import "common/ids.proto";
+message Event {
+  google.protobuf.Timestamp at = 1;
+  common.Id id = 2;
+}
+service Events {
+  rpc Get (Event) returns (Event) {
+    option (google.api.http) = { get: "/v1/events" };
+  }
+}
+`
+	diags, err := app.ValidatePB("demo.proto", content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags == nil || len(diags) != 0 {
+		t.Errorf("protoc diagnostics = %#v, want an empty slice", diags)
+	}
+	if builtin, _ := ws.Validate(context.Background(), []string{"demo.proto"}, map[string]string{"demo.proto": content}); hasErrors(builtin) {
+		t.Errorf("built-in diagnostics = %+v, want none", builtin)
+	}
+
+	diags, err = app.ValidatePB("demo.proto", "syntax = \"proto3\";\nimport \"google/protobuf/missing.proto\";\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Line != 2 || diags[0].Code != CodeProtoc {
+		t.Errorf("missing import diagnostics = %+v", diags)
+	}
+}
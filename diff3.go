@@ -0,0 +1,168 @@
+package main
+
+import (
+	"strings"
+)
+
+// maxDiffCells bounds the size of the line matching table. Files whose
+// changed regions exceed it are compared as one replaced block.
+const maxDiffCells = 4 << 20
+
+// MergeHunk is a region where the saved and the disk content depart from
+// their common base. Lines are without their line endings; BaseLine is the
+// one-based first line of the region in the base.
+type MergeHunk struct {
+	BaseLine int      `json:"baseLine"`
+	Base     []string `json:"base"`
+	Mine     []string `json:"mine"`
+	Theirs   []string `json:"theirs"`
+	// Conflict is set when both sides changed the region differently
+	Conflict bool `json:"conflict"`
+}
+
+// ThreeWayDiff compares the editor content (mine) and the disk content
+// (theirs) against the version both started from. Merged applies the
+// changes of both sides, with git-style conflict markers where they clash.
+type ThreeWayDiff struct {
+	Hunks     []MergeHunk `json:"hunks"`
+	Merged    string      `json:"merged"`
+	Conflicts int         `json:"conflicts"`
+}
+
+// splitLines splits text into lines without their endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// matchLines returns for each line of a the index of the line of b it is
+// matched with in a longest common subsequence, or -1
+func matchLines(a, b []string) []int {
+	match := make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+
+	// Common prefix and suffix need no table
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		match[start] = start
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && a[endA-1] == b[endB-1] {
+		endA--
+		endB--
+		match[endA] = endB
+	}
+
+	n, m := endA-start, endB-start
+	if n == 0 || m == 0 || (n+1)*(m+1) > maxDiffCells {
+		return match
+	}
+
+	// lcs[i][j] is the LCS length of a[start+i:endA] and b[start+j:endB]
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[start+i] == b[start+j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[start+i] == b[start+j]:
+			match[start+i] = start + j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
+
+// equalLines reports whether two line slices are identical
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// diff3 merges the changes from base to mine and from base to theirs. Base
+// lines kept by both sides anchor the comparison; between anchors, a region
+// changed by one side only takes that side, a region changed identically by
+// both takes the change, and any other region is a conflict.
+func diff3(base, mine, theirs string) *ThreeWayDiff {
+	b, m, t := splitLines(base), splitLines(mine), splitLines(theirs)
+	toMine, toTheirs := matchLines(b, m), matchLines(b, t)
+
+	result := &ThreeWayDiff{Hunks: []MergeHunk{}}
+	var merged []string
+	i, im, it := 0, 0, 0
+	for i < len(b) || im < len(m) || it < len(t) {
+		if i < len(b) && toMine[i] == im && toTheirs[i] == it {
+			merged = append(merged, b[i])
+			i, im, it = i+1, im+1, it+1
+			continue
+		}
+
+		// Find the next base line both sides kept
+		j := i
+		for j < len(b) && (toMine[j] < 0 || toTheirs[j] < 0) {
+			j++
+		}
+		endM, endT := len(m), len(t)
+		if j < len(b) {
+			endM, endT = toMine[j], toTheirs[j]
+		}
+
+		hunk := MergeHunk{BaseLine: i + 1, Base: b[i:j], Mine: m[im:endM], Theirs: t[it:endT]}
+		switch {
+		case equalLines(hunk.Mine, hunk.Base):
+			merged = append(merged, hunk.Theirs...)
+		case equalLines(hunk.Theirs, hunk.Base), equalLines(hunk.Mine, hunk.Theirs):
+			merged = append(merged, hunk.Mine...)
+		default:
+			hunk.Conflict = true
+			result.Conflicts++
+			merged = append(merged, "<<<<<<< editor")
+			merged = append(merged, hunk.Mine...)
+			merged = append(merged, "||||||| base")
+			merged = append(merged, hunk.Base...)
+			merged = append(merged, "=======")
+			merged = append(merged, hunk.Theirs...)
+			merged = append(merged, ">>>>>>> disk")
+		}
+		result.Hunks = append(result.Hunks, hunk)
+		i, im, it = j, endM, endT
+	}
+
+	if len(merged) > 0 {
+		result.Merged = strings.Join(merged, "\n") + "\n"
+	}
+	return result
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// formatIndent is the indentation of one nesting level
+const formatIndent = "  "
+
+// parseProto parses content without compiling it, stopping at the first
+// syntax error
+func parseProto(filename, content string) (*ast.FileNode, error) {
+	handler := reporter.NewHandler(reporter.NewReporter(
+		func(err reporter.ErrorWithPos) error { return err },
+		func(reporter.ErrorWithPos) {},
+	))
+	return parser.Parse(filename, strings.NewReader(content), handler)
+}
+
+// syntaxError describes a parse error by its position in the content
+func syntaxError(err error) error {
+	var posErr reporter.ErrorWithPos
+	if errors.As(err, &posErr) {
+		pos := posErr.Start()
+		return fmt.Errorf("line %d, column %d: %v", pos.Line, pos.Col, posErr.Unwrap())
+	}
+	return err
+}
+
+// formatProto reprints a proto canonically: two-space indentation, one
+// declaration per line, the file header ordered as syntax, package, options
+// and imports, options first in every body and sorted with the standard
+// ones before custom ones, blank lines around top-level definitions and at
+// most one blank line elsewhere. All comments are kept.
+func formatProto(content string) (string, error) {
+	file, err := parseProto("input.proto", content)
+	if err != nil {
+		return "", syntaxError(err)
+	}
+	p := &protoPrinter{file: file, lineStart: true}
+	p.print()
+	formatted := p.buf.String()
+
+	// Guard against a printer bug dropping part of the file
+	check, err := parseProto("formatted.proto", formatted)
+	if err != nil {
+		return "", fmt.Errorf("formatting produced an invalid file: %w", syntaxError(err))
+	}
+	if countComments(check) != countComments(file) {
+		return "", errors.New("formatting would drop comments")
+	}
+	return formatted, nil
+}
+
+// countComments returns the number of comments in file
+func countComments(file *ast.FileNode) int {
+	count := 0
+	items := file.Items()
+	for item, ok := items.First(); ok; item, ok = items.Next(item) {
+		if _, comment := file.GetItem(item); comment.IsValid() {
+			count++
+		}
+	}
+	return count
+}
+
+// protoPrinter writes the tokens of a parsed proto with canonical layout.
+// Comments are printed with the token they are attributed to, so reordering
+// declarations carries their comments along.
+type protoPrinter struct {
+	file *ast.FileNode
+	buf  strings.Builder
+
+	indent int
+	// prev is the last token written on the current line
+	prev string
+	// lineStart is set when nothing was written on the current line yet
+	lineStart bool
+	// cont marks a line continuing a declaration broken by a comment
+	cont bool
+	// blank requests a blank line before the next line
+	blank bool
+	// afterOpen is set right after an opening brace, where blank lines are dropped
+	afterOpen bool
+	// glue suppresses spaces between tokens, inside qualified names
+	glue bool
+	// joinable is set when the last line was ended by newline after a
+	// token, so a comment that followed that token can still join it
+	joinable bool
+}
+
+// print writes the whole file
+func (p *protoPrinter) print() {
+	f := p.file
+	var packages, options, imports, rest []ast.Node
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.PackageNode:
+			packages = append(packages, decl)
+		case *ast.OptionNode:
+			options = append(options, decl)
+		case *ast.ImportNode:
+			imports = append(imports, decl)
+		default:
+			rest = append(rest, decl)
+		}
+	}
+
+	started := false
+	group := func(decls []ast.Node) {
+		if len(decls) == 0 {
+			return
+		}
+		if started {
+			p.blank = true
+		}
+		for _, decl := range decls {
+			p.newline()
+			p.decl(decl)
+		}
+		started = true
+	}
+	switch {
+	case f.Syntax != nil:
+		group([]ast.Node{f.Syntax})
+	case f.Edition != nil:
+		group([]ast.Node{f.Edition})
+	}
+	group(packages)
+	group(sortOptions(options))
+	group(imports)
+
+	wasBlock := true
+	for _, decl := range rest {
+		block := isBlock(decl)
+		if started && (block || wasBlock) {
+			p.blank = true
+		}
+		p.newline()
+		p.decl(decl)
+		started, wasBlock = true, block
+	}
+
+	p.newline()
+	p.comments(f.EOF.Token())
+	p.newline()
+}
+
+// isBlock reports whether decl is a top-level definition with a body
+func isBlock(decl ast.Node) bool {
+	switch decl.(type) {
+	case *ast.MessageNode, *ast.EnumNode, *ast.ServiceNode, *ast.ExtendNode:
+		return true
+	}
+	return false
+}
+
+// decl writes a declaration, which starts on a fresh line
+func (p *protoPrinter) decl(decl ast.Node) {
+	switch n := decl.(type) {
+	case *ast.MessageNode:
+		p.block(n, n.OpenBrace, nodes(n.Decls), n.CloseBrace)
+	case *ast.GroupNode:
+		p.block(n, n.OpenBrace, nodes(n.Decls), n.CloseBrace)
+	case *ast.EnumNode:
+		p.block(n, n.OpenBrace, nodes(n.Decls), n.CloseBrace)
+	case *ast.OneofNode:
+		p.block(n, n.OpenBrace, nodes(n.Decls), n.CloseBrace)
+	case *ast.ExtendNode:
+		p.block(n, n.OpenBrace, nodes(n.Decls), n.CloseBrace)
+	case *ast.ServiceNode:
+		p.block(n, n.OpenBrace, nodes(n.Decls), n.CloseBrace)
+	case *ast.RPCNode:
+		if n.OpenBrace == nil {
+			p.node(n)
+			return
+		}
+		p.block(n, n.OpenBrace, nodes(n.Decls), n.CloseBrace)
+	case *ast.EmptyDeclNode:
+		// Stray semicolons are dropped
+		p.comments(n.Semicolon.Token())
+	default:
+		p.node(n)
+	}
+}
+
+// nodes converts declarations of a body to plain nodes
+func nodes[T ast.Node](decls []T) []ast.Node {
+	converted := make([]ast.Node, len(decls))
+	for i, d := range decls {
+		converted[i] = d
+	}
+	return converted
+}
+
+// block writes a declaration with a body: the tokens before the opening
+// brace, the body declarations indented with options first and set apart,
+// then the closing brace on its own line. An empty body stays on one line.
+func (p *protoPrinter) block(n ast.CompositeNode, openBrace *ast.RuneNode, decls []ast.Node, closeBrace *ast.RuneNode) {
+	for _, child := range n.Children() {
+		if child == openBrace {
+			break
+		}
+		p.node(child)
+	}
+	p.token(openBrace.Token())
+	p.afterOpen = true
+
+	var options, others []ast.Node
+	for _, decl := range decls {
+		if _, ok := decl.(*ast.OptionNode); ok {
+			options = append(options, decl)
+		} else {
+			others = append(others, decl)
+		}
+	}
+
+	p.indent++
+	for i, decl := range append(sortOptions(options), others...) {
+		if i == len(options) && i > 0 {
+			p.blank = true
+		}
+		p.newline()
+		p.decl(decl)
+	}
+	p.close(closeBrace, len(decls) == 0)
+}
+
+// close writes the closing brace of a block or message literal, after the
+// comments attributed to it, which stay indented with the body
+func (p *protoPrinter) close(brace *ast.RuneNode, empty bool) {
+	info := p.file.TokenInfo(brace.Token())
+	if empty && info.LeadingComments().Len() == 0 && !p.lineStart {
+		p.indent--
+		p.write(info.RawText())
+		p.trailing(info)
+		return
+	}
+	p.leading(info)
+	p.indent--
+	p.newline()
+	p.blank = false
+	p.write(info.RawText())
+	p.trailing(info)
+}
+
+// node writes the tokens of n, laying out compact options and message
+// literals
+func (p *protoPrinter) node(n ast.Node) {
+	switch n := n.(type) {
+	case ast.TerminalNode:
+		p.token(n.Token())
+	case *ast.CompactOptionsNode:
+		p.compactOptions(n)
+	case *ast.MessageLiteralNode:
+		p.messageLiteral(n)
+	case *ast.MessageFieldNode:
+		p.node(n.Name)
+		if n.Sep == nil {
+			p.write(":")
+		} else {
+			p.node(n.Sep)
+		}
+		p.node(n.Val)
+	case *ast.CompoundIdentNode, *ast.OptionNameNode, *ast.FieldReferenceNode,
+		*ast.NegativeIntLiteralNode, *ast.SignedFloatLiteralNode:
+		p.glued(n.(ast.CompositeNode))
+	case ast.CompositeNode:
+		for _, child := range n.Children() {
+			p.node(child)
+		}
+	}
+}
+
+// glued writes the tokens of a qualified name or signed number without
+// spaces between them
+func (p *protoPrinter) glued(n ast.CompositeNode) {
+	if p.glue {
+		for _, child := range n.Children() {
+			p.node(child)
+		}
+		return
+	}
+	for i, child := range n.Children() {
+		p.node(child)
+		if i == 0 {
+			p.glue = true
+		}
+	}
+	p.glue = false
+}
+
+// compactOptions writes bracketed field options, sorted like option
+// declarations
+func (p *protoPrinter) compactOptions(n *ast.CompactOptionsNode) {
+	p.token(n.OpenBracket.Token())
+	for i, opt := range sortOptions(nodes(n.Options)) {
+		if i > 0 {
+			p.write(",")
+		}
+		p.node(opt)
+	}
+	// Commas are rewritten above; their comments are kept
+	for _, comma := range n.Commas {
+		p.comments(comma.Token())
+	}
+	p.token(n.CloseBracket.Token())
+}
+
+// messageLiteral writes an option value in text format, one field per line
+// without separators
+func (p *protoPrinter) messageLiteral(n *ast.MessageLiteralNode) {
+	p.token(n.Open.Token())
+	p.afterOpen = true
+	p.indent++
+	for i, field := range n.Elements {
+		p.newline()
+		p.node(field)
+		if n.Seps[i] != nil {
+			p.comments(n.Seps[i].Token())
+		}
+	}
+	p.close(n.Close, len(n.Elements) == 0)
+}
+
+// token writes a token with its comments
+func (p *protoPrinter) token(tok ast.Token) {
+	info := p.file.TokenInfo(tok)
+	p.leading(info)
+	if p.lineStart && strings.Count(info.LeadingWhitespace(), "\n") > 1 {
+		p.blank = true
+	}
+	p.write(info.RawText())
+	p.trailing(info)
+}
+
+// comments writes the comments of a token that is itself dropped
+func (p *protoPrinter) comments(tok ast.Token) {
+	info := p.file.TokenInfo(tok)
+	p.leading(info)
+	p.trailing(info)
+}
+
+// leading writes the comments before a token
+func (p *protoPrinter) leading(info ast.NodeInfo) {
+	comments := info.LeadingComments()
+	for i := 0; i < comments.Len(); i++ {
+		p.comment(comments.Index(i))
+	}
+}
+
+// trailing writes the comments after a token
+func (p *protoPrinter) trailing(info ast.NodeInfo) {
+	comments := info.TrailingComments()
+	for i := 0; i < comments.Len(); i++ {
+		p.comment(comments.Index(i))
+	}
+}
+
+// comment writes a comment, on its own line if it was on its own line in
+// the source. Line comments, and block comments on their own line, end
+// the line.
+func (p *protoPrinter) comment(c ast.Comment) {
+	text := strings.TrimRight(c.RawText(), "\r\n")
+	ws := c.LeadingWhitespace()
+	ownLine := strings.Contains(ws, "\n") || p.buf.Len() == 0
+	if !ownLine && p.lineStart && p.joinable {
+		// A comment after a statement on the same line, attributed to the
+		// next declaration, stays on the statement's line
+		printed := strings.TrimSuffix(p.buf.String(), "\n")
+		p.buf.Reset()
+		p.buf.WriteString(printed + " " + text + "\n")
+		p.joinable = !strings.HasPrefix(text, "//")
+		return
+	}
+	if ownLine {
+		p.newline()
+		if strings.Count(ws, "\n") > 1 {
+			p.blank = true
+		}
+	}
+	p.write(text)
+	if ownLine || strings.HasPrefix(text, "//") {
+		// A declaration broken by a comment at its end of line continues
+		// one level deeper
+		p.buf.WriteString("\n")
+		p.lineStart = true
+		p.joinable = false
+		p.cont = !ownLine
+	}
+}
+
+// newline ends the current line, if anything was written on it
+func (p *protoPrinter) newline() {
+	if !p.lineStart {
+		p.buf.WriteString("\n")
+		p.lineStart = true
+		p.joinable = true
+	}
+	p.cont = false
+}
+
+// write writes text, indenting it at the start of a line and separating
+// it from the previous token on the line when needed
+func (p *protoPrinter) write(text string) {
+	if p.lineStart {
+		if p.blank && !p.afterOpen && p.buf.Len() > 0 {
+			p.buf.WriteString("\n")
+		}
+		indent := p.indent
+		if p.cont {
+			indent++
+		}
+		p.buf.WriteString(strings.Repeat(formatIndent, indent))
+	} else if !p.glue && spaceBetween(p.prev, text) {
+		p.buf.WriteString(" ")
+	}
+	p.buf.WriteString(text)
+	p.prev = text
+	p.joinable = false
+	p.lineStart = false
+	p.blank = false
+	p.afterOpen = false
+}
+
+// spaceBetween reports whether two adjacent tokens are separated by a space
+func spaceBetween(prev, next string) bool {
+	switch next {
+	case ";", ",", ")", "]", ">", ":":
+		return false
+	case "<":
+		return prev != "map"
+	case "}":
+		return prev != "{"
+	}
+	switch prev {
+	case "", "(", "[", "<":
+		return false
+	}
+	return true
+}
+
+// sortOptions orders option declarations with the standard options before
+// the custom ones, each by name
+func sortOptions(options []ast.Node) []ast.Node {
+	sorted := append([]ast.Node(nil), options...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ci, ni := optionKey(sorted[i].(*ast.OptionNode))
+		cj, nj := optionKey(sorted[j].(*ast.OptionNode))
+		if ci != cj {
+			return !ci
+		}
+		return ni < nj
+	})
+	return sorted
+}
+
+// optionKey returns whether an option is custom and its name
+func optionKey(opt *ast.OptionNode) (bool, string) {
+	return len(opt.Name.Parts) > 0 && opt.Name.Parts[0].IsExtension(), optionName(opt)
+}
+
+// FormatPB reprints proto content canonically, keeping its comments. It
+// fails on content that does not parse.
+func (a *App) FormatPB(content string) (string, error) {
+	return formatProto(content)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFormatPB checks the canonical layout, that comments survive
+// reordering and that formatting is idempotent
+func TestFormatPB(t *testing.T) {
+	input := `// Header
+
+syntax="proto3";
+import "google/api/annotations.proto"; // annotations
+option java_package="com.example";
+package   demo.v1 ;
+option go_package = "example.com/demo/v1;demov1";
+
+
+// Request message
+message  GetRequest{string id=1 [(foo.bar) = 1, deprecated=true];   // the id
+  map<string,int32> counts = 2;
+  // custom first in the source
+  option (my.opt) = true;
+  option deprecated = true;
+
+
+  repeated .demo.v1.Thing things = 3;
+  oneof kind { string a = 4; int32 b = 5; }
+  enum E { E_UNSPECIFIED = 0; NEG = -1; }
+  message Empty{}
+  // last in body
+}
+service S{rpc Get(GetRequest)returns(stream GetRequest){option (google.api.http)={get:"/v1/{id}" additional_bindings{get:"/x"},};}
+rpc Other(GetRequest) returns (GetRequest);
+}
+`
+	want := `// Header
+
+syntax = "proto3";
+
+package demo.v1;
+
+option go_package = "example.com/demo/v1;demov1";
+option java_package = "com.example";
+
+import "google/api/annotations.proto"; // annotations
+
+// Request message
+message GetRequest {
+  option deprecated = true;
+  // custom first in the source
+  option (my.opt) = true;
+
+  string id = 1 [deprecated = true, (foo.bar) = 1]; // the id
+  map<string, int32> counts = 2;
+
+  repeated .demo.v1.Thing things = 3;
+  oneof kind {
+    string a = 4;
+    int32 b = 5;
+  }
+  enum E {
+    E_UNSPECIFIED = 0;
+    NEG = -1;
+  }
+  message Empty {}
+  // last in body
+}
+
+service S {
+  rpc Get (GetRequest) returns (stream GetRequest) {
+    option (google.api.http) = {
+      get: "/v1/{id}"
+      additional_bindings: {
+        get: "/x"
+      }
+    };
+  }
+  rpc Other (GetRequest) returns (GetRequest);
+}
+`
+	app := NewApp()
+	got, err := app.FormatPB(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("FormatPB =\n%s\nwant\n%s", got, want)
+	}
+	if again, err := app.FormatPB(got); err != nil || again != got {
+		t.Errorf("formatting is not idempotent:\n%s", again)
+	}
+
+	// The bundled examples are already canonical
+	for _, name := range []string{"example.proto", "example_with_routes.proto", "custom_options.proto"} {
+		content, err := os.ReadFile(filepath.Join("pb", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := app.FormatPB(string(content)); err != nil || got != string(content) {
+			t.Errorf("%s changed by formatting (%v):\n%s", name, err, got)
+		}
+	}
+
+	if _, err := app.FormatPB("syntax = \"proto3\";\nmessage {"); err == nil {
+		t.Error("invalid proto formatted")
+	}
+}
+
+// TestFormatProto_Idempotent checks that formatting formatted output changes
+// nothing, for the repository protos and for comments placed mid-line
+func TestFormatProto_Idempotent(t *testing.T) {
+	inputs := map[string]string{
+		"trailing block": "syntax = \"proto3\";\nmessage A {\n  int32 a = 1; /* trailing block */ int32 b = 2;\n}\n",
+		"two blocks":     "syntax = \"proto3\";\nmessage A {\n  int32 a = 1; /* one */ /* two */ int32 b = 2; // line\n  int32 c = 3;\n}\n",
+	}
+	names, err := filepath.Glob(filepath.Join("pb", "*.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs[name] = string(content)
+	}
+
+	for name, input := range inputs {
+		once, err := formatProto(input)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if twice, err := formatProto(once); err != nil || twice != once {
+			t.Errorf("%s: formatting is not idempotent (%v):\n%s\nthen\n%s", name, err, once, twice)
+		}
+	}
+
+	got, err := formatProto(inputs["trailing block"])
+	if want := "syntax = \"proto3\";\n\nmessage A {\n  int32 a = 1; /* trailing block */\n  int32 b = 2;\n}\n"; err != nil || got != want {
+		t.Errorf("formatProto = %q, %v; want %q", got, err, want)
+	}
+}
+
+// TestSavePB_FormatOnSave checks that saves are formatted when the
+// workspace enables it and that unparsable content is still saved
+func TestSavePB_FormatOnSave(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"formatOnSave": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := app.SavePB("demo.proto", "syntax=\"proto3\";\nmessage A{string id=1;}", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "syntax = \"proto3\";\n\nmessage A {\n  string id = 1;\n}\n"
+	if !result.Saved || !result.Formatted || result.Content != want {
+		t.Errorf("save = %+v, want formatted content", result)
+	}
+	if content, _ := os.ReadFile(filepath.Join(ws.PBPath(), "demo.proto")); string(content) != want {
+		t.Errorf("saved %q, want %q", content, want)
+	}
+
+	broken := "syntax = \"proto3\";\nmessage A {"
+	result, err = app.SavePB("demo.proto", broken, result.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Saved || result.Formatted {
+		t.Errorf("save = %+v, want saved unformatted", result)
+	}
+	if content, _ := os.ReadFile(filepath.Join(ws.PBPath(), "demo.proto")); string(content) != broken {
+		t.Errorf("saved %q, want %q", content, broken)
+	}
+}
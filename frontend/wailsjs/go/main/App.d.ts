@@ -1,24 +1,99 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {main} from '../models';
+
+export function CancelGeneration(arg1:string):Promise<void>;
+
+export function CancelStream(arg1:string):Promise<void>;
+
+export function CheckBreaking(arg1:string,arg2:string):Promise<main.BreakingReport>;
+
+export function ChooseWorkspace():Promise<main.Workspace>;
+
+export function ClearGenerationCache():Promise<void>;
+
+export function CloseStreamSend(arg1:string):Promise<void>;
+
+export function CloseWorkspace(arg1:string):Promise<void>;
+
+export function CreatePBFromTemplate(arg1:string,arg2:string):Promise<string>;
+
+export function DeletePB(arg1:string):Promise<Array<string>>;
+
+export function DescribeServer(arg1:string,arg2:boolean):Promise<main.WorkspaceSchema>;
+
+export function DescribeWorkspace():Promise<main.WorkspaceSchema>;
 
 export function DownloadGeneratedFile(arg1:string):Promise<string>;
 
-export function GenerateGRPC(arg1:string,arg2:string):Promise<string>;
+export function ExportServerProtos(arg1:string,arg2:boolean,arg3:boolean):Promise<main.ExportResult>;
+
+export function FormatPB(arg1:string):Promise<string>;
+
+export function GenerateGRPC(arg1:string,arg2:string,arg3:string,arg4:string):Promise<main.GenerateResult>;
 
 export function GetCurrentUser(arg1:string):Promise<string>;
 
 export function GetGeneratedFiles():Promise<Array<Record<string, any>>>;
 
+export function GetGenerationJob(arg1:string):Promise<main.GenerationJob>;
+
+export function GetGenerationJobs():Promise<Array<main.GenerationJob>>;
+
+export function GetOutputSnapshots():Promise<Array<main.OutputSnapshot>>;
+
 export function GetPBFiles():Promise<Array<Record<string, any>>>;
 
+export function GetPBTree():Promise<main.ProtoNode>;
+
+export function GetRecentWorkspaces():Promise<Array<main.RecentWorkspace>>;
+
+export function GetWorkspace():Promise<main.Workspace>;
+
+export function InvokeRPC(arg1:main.InvokeRequest):Promise<main.InvokeResult>;
+
+export function LintPB(arg1:string,arg2:string):Promise<Array<main.Diagnostic>>;
+
+export function LintWorkspace():Promise<Array<main.Diagnostic>>;
+
+export function ListBaselines():Promise<Array<main.Baseline>>;
+
+export function ListLintRules():Promise<Array<main.LintRule>>;
+
+export function ListPBTemplates():Promise<Array<main.PBTemplate>>;
+
+export function ListWorkspaces():Promise<Array<main.Workspace>>;
+
 export function LoginUser(arg1:string,arg2:string):Promise<string>;
 
 export function LogoutUser(arg1:string):Promise<string>;
 
+export function OpenWorkspace(arg1:string):Promise<main.Workspace>;
+
 export function ReadGeneratedFile(arg1:string):Promise<string>;
 
-export function ReadPB(arg1:string):Promise<string>;
+export function ReadPB(arg1:string):Promise<main.PBFile>;
 
 export function RegisterUser(arg1:string,arg2:string,arg3:string):Promise<string>;
 
-export function SavePB(arg1:string,arg2:string):Promise<string>;
+export function RenamePB(arg1:string,arg2:string):Promise<main.RenameResult>;
+
+export function RestoreOutputSnapshot(arg1:string):Promise<void>;
+
+export function SaveBaseline(arg1:string):Promise<main.Baseline>;
+
+export function SavePB(arg1:string,arg2:string,arg3:string):Promise<main.SaveResult>;
+
+export function SendStreamMessage(arg1:string,arg2:string):Promise<void>;
+
+export function SetEditorState(arg1:string,arg2:boolean):Promise<void>;
+
+export function StartGeneration(arg1:string,arg2:string,arg3:string,arg4:string):Promise<string>;
+
+export function StartStream(arg1:main.InvokeRequest):Promise<main.StreamInfo>;
+
+export function SwitchWorkspace(arg1:string):Promise<main.Workspace>;
+
+export function UpdateWorkspaceConfig(arg1:Record<string, any>):Promise<main.Workspace>;
+
+export function ValidatePB(arg1:string,arg2:string):Promise<Array<main.Diagnostic>>;
@@ -2,12 +2,64 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelGeneration(arg1) {
+  return window['go']['main']['App']['CancelGeneration'](arg1);
+}
+
+export function CancelStream(arg1) {
+  return window['go']['main']['App']['CancelStream'](arg1);
+}
+
+export function CheckBreaking(arg1, arg2) {
+  return window['go']['main']['App']['CheckBreaking'](arg1, arg2);
+}
+
+export function ChooseWorkspace() {
+  return window['go']['main']['App']['ChooseWorkspace']();
+}
+
+export function ClearGenerationCache() {
+  return window['go']['main']['App']['ClearGenerationCache']();
+}
+
+export function CloseStreamSend(arg1) {
+  return window['go']['main']['App']['CloseStreamSend'](arg1);
+}
+
+export function CloseWorkspace(arg1) {
+  return window['go']['main']['App']['CloseWorkspace'](arg1);
+}
+
+export function CreatePBFromTemplate(arg1, arg2) {
+  return window['go']['main']['App']['CreatePBFromTemplate'](arg1, arg2);
+}
+
+export function DeletePB(arg1) {
+  return window['go']['main']['App']['DeletePB'](arg1);
+}
+
+export function DescribeServer(arg1, arg2) {
+  return window['go']['main']['App']['DescribeServer'](arg1, arg2);
+}
+
+export function DescribeWorkspace() {
+  return window['go']['main']['App']['DescribeWorkspace']();
+}
+
 export function DownloadGeneratedFile(arg1) {
   return window['go']['main']['App']['DownloadGeneratedFile'](arg1);
 }
 
-export function GenerateGRPC(arg1, arg2) {
-  return window['go']['main']['App']['GenerateGRPC'](arg1, arg2);
+export function ExportServerProtos(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportServerProtos'](arg1, arg2, arg3);
+}
+
+export function FormatPB(arg1) {
+  return window['go']['main']['App']['FormatPB'](arg1);
+}
+
+export function GenerateGRPC(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['GenerateGRPC'](arg1, arg2, arg3, arg4);
 }
 
 export function GetCurrentUser(arg1) {
@@ -18,10 +70,62 @@ export function GetGeneratedFiles() {
   return window['go']['main']['App']['GetGeneratedFiles']();
 }
 
+export function GetGenerationJob(arg1) {
+  return window['go']['main']['App']['GetGenerationJob'](arg1);
+}
+
+export function GetGenerationJobs() {
+  return window['go']['main']['App']['GetGenerationJobs']();
+}
+
+export function GetOutputSnapshots() {
+  return window['go']['main']['App']['GetOutputSnapshots']();
+}
+
 export function GetPBFiles() {
   return window['go']['main']['App']['GetPBFiles']();
 }
 
+export function GetPBTree() {
+  return window['go']['main']['App']['GetPBTree']();
+}
+
+export function GetRecentWorkspaces() {
+  return window['go']['main']['App']['GetRecentWorkspaces']();
+}
+
+export function GetWorkspace() {
+  return window['go']['main']['App']['GetWorkspace']();
+}
+
+export function InvokeRPC(arg1) {
+  return window['go']['main']['App']['InvokeRPC'](arg1);
+}
+
+export function LintPB(arg1, arg2) {
+  return window['go']['main']['App']['LintPB'](arg1, arg2);
+}
+
+export function LintWorkspace() {
+  return window['go']['main']['App']['LintWorkspace']();
+}
+
+export function ListBaselines() {
+  return window['go']['main']['App']['ListBaselines']();
+}
+
+export function ListLintRules() {
+  return window['go']['main']['App']['ListLintRules']();
+}
+
+export function ListPBTemplates() {
+  return window['go']['main']['App']['ListPBTemplates']();
+}
+
+export function ListWorkspaces() {
+  return window['go']['main']['App']['ListWorkspaces']();
+}
+
 export function LoginUser(arg1, arg2) {
   return window['go']['main']['App']['LoginUser'](arg1, arg2);
 }
@@ -30,6 +134,10 @@ export function LogoutUser(arg1) {
   return window['go']['main']['App']['LogoutUser'](arg1);
 }
 
+export function OpenWorkspace(arg1) {
+  return window['go']['main']['App']['OpenWorkspace'](arg1);
+}
+
 export function ReadGeneratedFile(arg1) {
   return window['go']['main']['App']['ReadGeneratedFile'](arg1);
 }
@@ -42,6 +150,46 @@ export function RegisterUser(arg1, arg2, arg3) {
   return window['go']['main']['App']['RegisterUser'](arg1, arg2, arg3);
 }
 
-export function SavePB(arg1, arg2) {
-  return window['go']['main']['App']['SavePB'](arg1, arg2);
+export function RenamePB(arg1, arg2) {
+  return window['go']['main']['App']['RenamePB'](arg1, arg2);
+}
+
+export function RestoreOutputSnapshot(arg1) {
+  return window['go']['main']['App']['RestoreOutputSnapshot'](arg1);
+}
+
+export function SaveBaseline(arg1) {
+  return window['go']['main']['App']['SaveBaseline'](arg1);
+}
+
+export function SavePB(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SavePB'](arg1, arg2, arg3);
+}
+
+export function SendStreamMessage(arg1, arg2) {
+  return window['go']['main']['App']['SendStreamMessage'](arg1, arg2);
+}
+
+export function SetEditorState(arg1, arg2) {
+  return window['go']['main']['App']['SetEditorState'](arg1, arg2);
+}
+
+export function StartGeneration(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['StartGeneration'](arg1, arg2, arg3, arg4);
+}
+
+export function StartStream(arg1) {
+  return window['go']['main']['App']['StartStream'](arg1);
+}
+
+export function SwitchWorkspace(arg1) {
+  return window['go']['main']['App']['SwitchWorkspace'](arg1);
+}
+
+export function UpdateWorkspaceConfig(arg1) {
+  return window['go']['main']['App']['UpdateWorkspaceConfig'](arg1);
+}
+
+export function ValidatePB(arg1, arg2) {
+  return window['go']['main']['App']['ValidatePB'](arg1, arg2);
 }
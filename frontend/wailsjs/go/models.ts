@@ -0,0 +1,1324 @@
+export namespace main {
+	
+	export class Baseline {
+	    name: string;
+	    path: string;
+	    files: number;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Baseline(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.files = source["files"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BreakingChange {
+	    category: string;
+	    subject: string;
+	    file: string;
+	    line: number;
+	    column: number;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BreakingChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.category = source["category"];
+	        this.subject = source["subject"];
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.column = source["column"];
+	        this.message = source["message"];
+	    }
+	}
+	export class BreakingReport {
+	    baseline: string;
+	    changes: BreakingChange[];
+	    categories: Record<string, number>;
+	
+	    static createFrom(source: any = {}) {
+	        return new BreakingReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.baseline = source["baseline"];
+	        this.changes = this.convertValues(source["changes"], BreakingChange);
+	        this.categories = source["categories"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ManagedOptions {
+	    goPackagePrefix: string;
+	    except?: string[];
+	    override?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new ManagedOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.goPackagePrefix = source["goPackagePrefix"];
+	        this.except = source["except"];
+	        this.override = source["override"];
+	    }
+	}
+	export class PluginConfig {
+	    name: string;
+	    path?: string;
+	    out?: string;
+	    opt?: string[];
+	    optional?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PluginConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.out = source["out"];
+	        this.opt = source["opt"];
+	        this.optional = source["optional"];
+	    }
+	}
+	export class BufDep {
+	    module: string;
+	    dir?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BufDep(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.module = source["module"];
+	        this.dir = source["dir"];
+	    }
+	}
+	export class BufModule {
+	    path: string;
+	    name?: string;
+	    excludes?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new BufModule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.name = source["name"];
+	        this.excludes = source["excludes"];
+	    }
+	}
+	export class BufConfig {
+	    modules: BufModule[];
+	    deps?: BufDep[];
+	    plugins?: PluginConfig[];
+	    managed?: ManagedOptions;
+	
+	    static createFrom(source: any = {}) {
+	        return new BufConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.modules = this.convertValues(source["modules"], BufModule);
+	        this.deps = this.convertValues(source["deps"], BufDep);
+	        this.plugins = this.convertValues(source["plugins"], PluginConfig);
+	        this.managed = this.convertValues(source["managed"], ManagedOptions);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class Diagnostic {
+	    file: string;
+	    line: number;
+	    column: number;
+	    endLine?: number;
+	    endColumn?: number;
+	    severity: string;
+	    message: string;
+	    code: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Diagnostic(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.column = source["column"];
+	        this.endLine = source["endLine"];
+	        this.endColumn = source["endColumn"];
+	        this.severity = source["severity"];
+	        this.message = source["message"];
+	        this.code = source["code"];
+	    }
+	}
+	export class ExportResult {
+	    target: string;
+	    protocol: string;
+	    written: string[];
+	    skipped: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.target = source["target"];
+	        this.protocol = source["protocol"];
+	        this.written = source["written"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+	export class GenerateEvent {
+	    jobId: string;
+	    step: string;
+	    plugin?: string;
+	    stream?: string;
+	    status?: string;
+	    message: string;
+	    // Go type: time
+	    time: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerateEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.jobId = source["jobId"];
+	        this.step = source["step"];
+	        this.plugin = source["plugin"];
+	        this.stream = source["stream"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	        this.time = this.convertValues(source["time"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PluginResult {
+	    name: string;
+	    path: string;
+	    out?: string;
+	    status: string;
+	    message?: string;
+	    files: string[];
+	    reused: string[];
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PluginResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.out = source["out"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	        this.files = source["files"];
+	        this.reused = source["reused"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class RemovedFile {
+	    name: string;
+	    out: string;
+	    plugin: string;
+	    proto: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RemovedFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.out = source["out"];
+	        this.plugin = source["plugin"];
+	        this.proto = source["proto"];
+	    }
+	}
+	export class GeneratedFile {
+	    name: string;
+	    out: string;
+	    plugin: string;
+	    size: number;
+	    sha256: string;
+	    reused: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new GeneratedFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.out = source["out"];
+	        this.plugin = source["plugin"];
+	        this.size = source["size"];
+	        this.sha256 = source["sha256"];
+	        this.reused = source["reused"];
+	    }
+	}
+	export class MergeHunk {
+	    baseLine: number;
+	    base: string[];
+	    mine: string[];
+	    theirs: string[];
+	    conflict: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MergeHunk(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.baseLine = source["baseLine"];
+	        this.base = source["base"];
+	        this.mine = source["mine"];
+	        this.theirs = source["theirs"];
+	        this.conflict = source["conflict"];
+	    }
+	}
+	export class ThreeWayDiff {
+	    hunks: MergeHunk[];
+	    merged: string;
+	    conflicts: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ThreeWayDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hunks = this.convertValues(source["hunks"], MergeHunk);
+	        this.merged = source["merged"];
+	        this.conflicts = source["conflicts"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SaveConflict {
+	    path: string;
+	    baseVersion: string;
+	    diskVersion: string;
+	    disk: string;
+	    diff?: ThreeWayDiff;
+	
+	    static createFrom(source: any = {}) {
+	        return new SaveConflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.baseVersion = source["baseVersion"];
+	        this.diskVersion = source["diskVersion"];
+	        this.disk = source["disk"];
+	        this.diff = this.convertValues(source["diff"], ThreeWayDiff);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SaveResult {
+	    saved: boolean;
+	    path: string;
+	    size: number;
+	    version: string;
+	    message: string;
+	    formatted?: boolean;
+	    content?: string;
+	    conflict?: SaveConflict;
+	
+	    static createFrom(source: any = {}) {
+	        return new SaveResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.saved = source["saved"];
+	        this.path = source["path"];
+	        this.size = source["size"];
+	        this.version = source["version"];
+	        this.message = source["message"];
+	        this.formatted = source["formatted"];
+	        this.content = source["content"];
+	        this.conflict = this.convertValues(source["conflict"], SaveConflict);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class GenerateResult {
+	    jobId: string;
+	    status: string;
+	    file: string;
+	    version: string;
+	    save?: SaveResult;
+	    scope: string;
+	    protos: string[];
+	    outputDir: string;
+	    files: GeneratedFile[];
+	    removed: RemovedFile[];
+	    reused: number;
+	    regenerated: number;
+	    plugins: PluginResult[];
+	    diagnostics: Diagnostic[];
+	    commands: string[];
+	    durationMs: number;
+	    summary: string;
+	    discarded: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerateResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.jobId = source["jobId"];
+	        this.status = source["status"];
+	        this.file = source["file"];
+	        this.version = source["version"];
+	        this.save = this.convertValues(source["save"], SaveResult);
+	        this.scope = source["scope"];
+	        this.protos = source["protos"];
+	        this.outputDir = source["outputDir"];
+	        this.files = this.convertValues(source["files"], GeneratedFile);
+	        this.removed = this.convertValues(source["removed"], RemovedFile);
+	        this.reused = source["reused"];
+	        this.regenerated = source["regenerated"];
+	        this.plugins = this.convertValues(source["plugins"], PluginResult);
+	        this.diagnostics = this.convertValues(source["diagnostics"], Diagnostic);
+	        this.commands = source["commands"];
+	        this.durationMs = source["durationMs"];
+	        this.summary = source["summary"];
+	        this.discarded = source["discarded"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class GenerationJob {
+	    id: string;
+	    workspace: string;
+	    file: string;
+	    scope: string;
+	    state: string;
+	    status?: string;
+	    coalesced: number;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    startedAt: any;
+	    // Go type: time
+	    finishedAt: any;
+	    events?: GenerateEvent[];
+	    result?: GenerateResult;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerationJob(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.workspace = source["workspace"];
+	        this.file = source["file"];
+	        this.scope = source["scope"];
+	        this.state = source["state"];
+	        this.status = source["status"];
+	        this.coalesced = source["coalesced"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.startedAt = this.convertValues(source["startedAt"], null);
+	        this.finishedAt = this.convertValues(source["finishedAt"], null);
+	        this.events = this.convertValues(source["events"], GenerateEvent);
+	        this.result = this.convertValues(source["result"], GenerateResult);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HTTPBinding {
+	    method: string;
+	    path: string;
+	    body?: string;
+	    responseBody?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HTTPBinding(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.method = source["method"];
+	        this.path = source["path"];
+	        this.body = source["body"];
+	        this.responseBody = source["responseBody"];
+	    }
+	}
+	export class InvokeRequest {
+	    target: string;
+	    method: string;
+	    body: string;
+	    metadata: Record<string, string>;
+	    tls: boolean;
+	    timeoutMs: number;
+	    reflection: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new InvokeRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.target = source["target"];
+	        this.method = source["method"];
+	        this.body = source["body"];
+	        this.metadata = source["metadata"];
+	        this.tls = source["tls"];
+	        this.timeoutMs = source["timeoutMs"];
+	        this.reflection = source["reflection"];
+	    }
+	}
+	export class InvokeResult {
+	    method: string;
+	    response: string;
+	    headers: Record<string, Array<string>>;
+	    trailers: Record<string, Array<string>>;
+	    code: string;
+	    message?: string;
+	    latencyMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new InvokeResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.method = source["method"];
+	        this.response = source["response"];
+	        this.headers = source["headers"];
+	        this.trailers = source["trailers"];
+	        this.code = source["code"];
+	        this.message = source["message"];
+	        this.latencyMs = source["latencyMs"];
+	    }
+	}
+	export class LintRule {
+	    id: string;
+	    description: string;
+	    severity: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LintRule(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.description = source["description"];
+	        this.severity = source["severity"];
+	    }
+	}
+	
+	
+	export class OutputSnapshot {
+	    outDir: string;
+	    path: string;
+	    files: number;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new OutputSnapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.outDir = source["outDir"];
+	        this.path = source["path"];
+	        this.files = source["files"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PBFile {
+	    path: string;
+	    content: string;
+	    version: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PBFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.content = source["content"];
+	        this.version = source["version"];
+	    }
+	}
+	export class PBTemplate {
+	    name: string;
+	    title: string;
+	    description: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PBTemplate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.title = source["title"];
+	        this.description = source["description"];
+	    }
+	}
+	
+	
+	export class ProtoNode {
+	    name: string;
+	    path: string;
+	    isDir: boolean;
+	    package?: string;
+	    goPackage?: string;
+	    size: number;
+	    modified: string;
+	    children?: ProtoNode[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProtoNode(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.isDir = source["isDir"];
+	        this.package = source["package"];
+	        this.goPackage = source["goPackage"];
+	        this.size = source["size"];
+	        this.modified = source["modified"];
+	        this.children = this.convertValues(source["children"], ProtoNode);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecentWorkspace {
+	    name: string;
+	    root: string;
+	    // Go type: time
+	    openedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecentWorkspace(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.root = source["root"];
+	        this.openedAt = this.convertValues(source["openedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class RenameResult {
+	    from: string;
+	    to: string;
+	    updated: string[];
+	    unchanged: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RenameResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.updated = source["updated"];
+	        this.unchanged = source["unchanged"];
+	    }
+	}
+	
+	
+	export class SchemaEnumValue {
+	    name: string;
+	    fullName: string;
+	    file: string;
+	    line: number;
+	    comment?: string;
+	    options: SchemaOption[];
+	    number: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SchemaEnumValue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.fullName = source["fullName"];
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.comment = source["comment"];
+	        this.options = this.convertValues(source["options"], SchemaOption);
+	        this.number = source["number"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SchemaOption {
+	    name: string;
+	    extension: boolean;
+	    value: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SchemaOption(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.extension = source["extension"];
+	        this.value = source["value"];
+	    }
+	}
+	export class SchemaEnum {
+	    name: string;
+	    fullName: string;
+	    file: string;
+	    line: number;
+	    comment?: string;
+	    options: SchemaOption[];
+	    values: SchemaEnumValue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SchemaEnum(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.fullName = source["fullName"];
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.comment = source["comment"];
+	        this.options = this.convertValues(source["options"], SchemaOption);
+	        this.values = this.convertValues(source["values"], SchemaEnumValue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SchemaField {
+	    name: string;
+	    fullName: string;
+	    file: string;
+	    line: number;
+	    comment?: string;
+	    options: SchemaOption[];
+	    number: number;
+	    type: string;
+	    label?: string;
+	    jsonName: string;
+	    oneof?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SchemaField(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.fullName = source["fullName"];
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.comment = source["comment"];
+	        this.options = this.convertValues(source["options"], SchemaOption);
+	        this.number = source["number"];
+	        this.type = source["type"];
+	        this.label = source["label"];
+	        this.jsonName = source["jsonName"];
+	        this.oneof = source["oneof"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SchemaMessage {
+	    name: string;
+	    fullName: string;
+	    file: string;
+	    line: number;
+	    comment?: string;
+	    options: SchemaOption[];
+	    fields: SchemaField[];
+	    messages: SchemaMessage[];
+	    enums: SchemaEnum[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SchemaMessage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.fullName = source["fullName"];
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.comment = source["comment"];
+	        this.options = this.convertValues(source["options"], SchemaOption);
+	        this.fields = this.convertValues(source["fields"], SchemaField);
+	        this.messages = this.convertValues(source["messages"], SchemaMessage);
+	        this.enums = this.convertValues(source["enums"], SchemaEnum);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SchemaMethod {
+	    name: string;
+	    fullName: string;
+	    file: string;
+	    line: number;
+	    comment?: string;
+	    options: SchemaOption[];
+	    input: string;
+	    output: string;
+	    clientStreaming: boolean;
+	    serverStreaming: boolean;
+	    streaming: string;
+	    http: HTTPBinding[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SchemaMethod(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.fullName = source["fullName"];
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.comment = source["comment"];
+	        this.options = this.convertValues(source["options"], SchemaOption);
+	        this.input = source["input"];
+	        this.output = source["output"];
+	        this.clientStreaming = source["clientStreaming"];
+	        this.serverStreaming = source["serverStreaming"];
+	        this.streaming = source["streaming"];
+	        this.http = this.convertValues(source["http"], HTTPBinding);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SchemaService {
+	    name: string;
+	    fullName: string;
+	    file: string;
+	    line: number;
+	    comment?: string;
+	    options: SchemaOption[];
+	    methods: SchemaMethod[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SchemaService(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.fullName = source["fullName"];
+	        this.file = source["file"];
+	        this.line = source["line"];
+	        this.comment = source["comment"];
+	        this.options = this.convertValues(source["options"], SchemaOption);
+	        this.methods = this.convertValues(source["methods"], SchemaMethod);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SchemaPackage {
+	    name: string;
+	    files: string[];
+	    services: SchemaService[];
+	    messages: SchemaMessage[];
+	    enums: SchemaEnum[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SchemaPackage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.files = source["files"];
+	        this.services = this.convertValues(source["services"], SchemaService);
+	        this.messages = this.convertValues(source["messages"], SchemaMessage);
+	        this.enums = this.convertValues(source["enums"], SchemaEnum);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class StreamInfo {
+	    id: string;
+	    method: string;
+	    streaming: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new StreamInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.method = source["method"];
+	        this.streaming = source["streaming"];
+	    }
+	}
+	
+	export class WorkspaceConfig {
+	    pbDir: string;
+	    outputDir: string;
+	    includeDir: string;
+	    protoc?: string;
+	    plugins?: PluginConfig[];
+	    formatOnSave?: boolean;
+	    lint?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new WorkspaceConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pbDir = source["pbDir"];
+	        this.outputDir = source["outputDir"];
+	        this.includeDir = source["includeDir"];
+	        this.protoc = source["protoc"];
+	        this.plugins = this.convertValues(source["plugins"], PluginConfig);
+	        this.formatOnSave = source["formatOnSave"];
+	        this.lint = source["lint"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Workspace {
+	    name: string;
+	    root: string;
+	    config: WorkspaceConfig;
+	    buf?: BufConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new Workspace(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.root = source["root"];
+	        this.config = this.convertValues(source["config"], WorkspaceConfig);
+	        this.buf = this.convertValues(source["buf"], BufConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class WorkspaceSchema {
+	    workspace: string;
+	    packages: SchemaPackage[];
+	
+	    static createFrom(source: any = {}) {
+	        return new WorkspaceSchema(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.workspace = source["workspace"];
+	        this.packages = this.convertValues(source["packages"], SchemaPackage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// Generation and plugin statuses
+const (
+	StatusSuccess  = "success"
+	StatusPartial  = "partial"
+	StatusFailed   = "failed"
+	StatusSkipped  = "skipped"
+	StatusCanceled = "canceled"
+)
+
+// Generation scopes, choosing which protos a run generates code for
+const (
+	// ScopeFile generates the saved proto only
+	ScopeFile = "file"
+	// ScopeDependents adds every workspace proto importing the saved one
+	ScopeDependents = "dependents"
+	// ScopeWorkspace generates every proto of the workspace
+	ScopeWorkspace = "workspace"
+)
+
+// builtinPluginPath is the plugin path of in-process generators
+const builtinPluginPath = "builtin"
+
+// GeneratedFile is an output file written by a generation run. Name is
+// relative to Out, the output directory of the plugin that wrote it.
+type GeneratedFile struct {
+	Name   string `json:"name"`
+	Out    string `json:"out"`
+	Plugin string `json:"plugin"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// Reused is set when the file was kept from a cached generation
+	Reused bool `json:"reused"`
+}
+
+// PluginResult is the outcome of running one code generator
+type PluginResult struct {
+	Name       string   `json:"name"`
+	Path       string   `json:"path"`
+	Out        string   `json:"out,omitempty"`
+	Status     string   `json:"status"`
+	Message    string   `json:"message,omitempty"`
+	Files      []string `json:"files"`
+	Reused     []string `json:"reused"`
+	DurationMs int64    `json:"durationMs"`
+}
+
+// GenerateResult describes a GenerateGRPC run for the frontend. Version is
+// the version of the proto as the run saved it, to be passed to SavePB.
+// Save is the outcome of saving the proto; when it reports a conflict
+// nothing was generated.
+type GenerateResult struct {
+	JobID       string          `json:"jobId"`
+	Status      string          `json:"status"`
+	File        string          `json:"file"`
+	Version     string          `json:"version"`
+	Save        *SaveResult     `json:"save,omitempty"`
+	Scope       string          `json:"scope"`
+	Protos      []string        `json:"protos"`
+	OutputDir   string          `json:"outputDir"`
+	Files       []GeneratedFile `json:"files"`
+	Removed     []RemovedFile   `json:"removed"`
+	Reused      int             `json:"reused"`
+	Regenerated int             `json:"regenerated"`
+	Plugins     []PluginResult  `json:"plugins"`
+	Diagnostics []Diagnostic    `json:"diagnostics"`
+	Commands    []string        `json:"commands"`
+	DurationMs  int64           `json:"durationMs"`
+	Summary     string          `json:"summary"`
+	// Discarded is set when the run's output was thrown away rather than
+	// swapped in, leaving the previous output in place
+	Discarded bool `json:"discarded"`
+}
+
+// newGenerateResult returns an empty result for file
+func newGenerateResult(file string) *GenerateResult {
+	return &GenerateResult{
+		File:        file,
+		Protos:      []string{},
+		Files:       []GeneratedFile{},
+		Removed:     []RemovedFile{},
+		Plugins:     []PluginResult{},
+		Diagnostics: []Diagnostic{},
+		Commands:    []string{},
+	}
+}
+
+// fail marks the result failed with a summary line
+func (r *GenerateResult) fail(format string, args ...interface{}) *GenerateResult {
+	r.Status = StatusFailed
+	r.Summary = fmt.Sprintf(format, args...)
+	return r
+}
+
+// finish sets the overall status from the plugin outcomes and builds the summary
+func (r *GenerateResult) finish(start time.Time) *GenerateResult {
+	r.DurationMs = time.Since(start).Milliseconds()
+
+	if r.Status == "" {
+		r.Status = pipelineStatus(r.Plugins)
+	}
+	sort.Slice(r.Files, func(i, j int) bool {
+		if r.Files[i].Out != r.Files[j].Out {
+			return r.Files[i].Out < r.Files[j].Out
+		}
+		return r.Files[i].Name < r.Files[j].Name
+	})
+	sort.Slice(r.Removed, func(i, j int) bool {
+		if r.Removed[i].Out != r.Removed[j].Out {
+			return r.Removed[i].Out < r.Removed[j].Out
+		}
+		return r.Removed[i].Name < r.Removed[j].Name
+	})
+
+	var b strings.Builder
+	switch r.Status {
+	case StatusSuccess:
+		fmt.Fprintf(&b, "GRPC code generated successfully! Output saved to %s", r.OutputDir)
+	case StatusPartial:
+		if r.Discarded {
+			b.WriteString("GRPC code generation failed for a required plugin")
+		} else {
+			fmt.Fprintf(&b, "GRPC code generated with problems. Output saved to %s", r.OutputDir)
+		}
+	case StatusCanceled:
+		b.WriteString("GRPC code generation canceled")
+	default:
+		if r.Summary == "" {
+			r.Summary = "GRPC code generation failed"
+		}
+		b.WriteString(r.Summary)
+	}
+	if r.Discarded {
+		b.WriteString("\nOutput discarded, previous output kept")
+	}
+	fmt.Fprintf(&b, "\nFile: %s\nDuration: %dms", r.File, r.DurationMs)
+	if len(r.Protos) > 1 {
+		fmt.Fprintf(&b, "\nScope: %s (%s)", r.Scope, strings.Join(r.Protos, ", "))
+	}
+
+	for _, d := range r.Diagnostics {
+		fmt.Fprintf(&b, "\n  %s", d)
+	}
+	for _, p := range r.Plugins {
+		fmt.Fprintf(&b, "\n[%s] %s (%dms)", p.Status, p.Name, p.DurationMs)
+		if p.Message != "" {
+			fmt.Fprintf(&b, ": %s", p.Message)
+		}
+	}
+	if len(r.Files) > 0 {
+		fmt.Fprintf(&b, "\nRegenerated %d, reused %d unchanged", r.Regenerated, r.Reused)
+		b.WriteString("\nGenerated files:")
+		for _, f := range r.Files {
+			name := f.Name
+			if f.Out != r.OutputDir {
+				name = filepath.Join(f.Out, filepath.FromSlash(f.Name))
+			}
+			if f.Reused {
+				fmt.Fprintf(&b, "\n  - %s (size: %d bytes, reused)", name, f.Size)
+			} else {
+				fmt.Fprintf(&b, "\n  - %s (size: %d bytes)", name, f.Size)
+			}
+		}
+	}
+	if len(r.Removed) > 0 {
+		b.WriteString("\nRemoved orphaned files:")
+		for _, f := range r.Removed {
+			name := f.Name
+			if f.Out != r.OutputDir {
+				name = filepath.Join(f.Out, filepath.FromSlash(f.Name))
+			}
+			fmt.Fprintf(&b, "\n  - %s (from %s)", name, f.Proto)
+		}
+	}
+
+	r.Summary = b.String()
+	return r
+}
+
+// pipelineStatus is the overall status of a run from its plugin outcomes:
+// failed when no plugin succeeded and one failed, partial when some plugin
+// did not succeed
+func pipelineStatus(plugins []PluginResult) string {
+	succeeded, failed := 0, 0
+	for _, p := range plugins {
+		switch p.Status {
+		case StatusSuccess:
+			succeeded++
+		case StatusFailed:
+			failed++
+		}
+	}
+	switch {
+	case succeeded == 0 && failed > 0:
+		return StatusFailed
+	case succeeded < len(plugins):
+		return StatusPartial
+	}
+	return StatusSuccess
+}
+
+// runGenerator runs one pipeline step over files from dir and records its
+// outcome and outputs in the result. Files whose cache entry is current
+// reuse their previous outputs; the others are generated one request per
+// file so their outputs can be cached separately. Outputs a proto no
+// longer produces are removed.
+func (r *GenerateResult) runGenerator(job *generateJob, dir string, gen *generator, files []protoreflect.FileDescriptor, cache *generateCache, manifest *outputManifest) error {
+	start := time.Now()
+	job.progress(StepPluginStart, gen.Name, "", "Running %s", gen.Name)
+	req := newCodeGeneratorRequest(files, gen.Parameter)
+	if gen.prepare != nil {
+		gen.prepare(req)
+	}
+	keys := generationKeys(gen, req)
+
+	plugin := PluginResult{
+		Name:   gen.Name,
+		Path:   gen.Path,
+		Out:    gen.OutDir,
+		Status: StatusSuccess,
+		Files:  []string{},
+		Reused: []string{},
+	}
+
+	writeDir := func(string) string { return gen.writeDir() }
+	var err error
+	for _, file := range req.GetFileToGenerate() {
+		if err = job.ctx.Err(); err != nil {
+			break
+		}
+		id := cacheEntryID(gen, file)
+		if outputs, ok := cache.lookup(id, keys[file], gen.writeDir()); ok {
+			names := make([]string, 0, len(outputs))
+			for _, out := range outputs {
+				plugin.Reused = append(plugin.Reused, out.Name)
+				r.addFile(gen, out.Name, true)
+				names = append(names, out.Name)
+			}
+			r.removeOutputs(manifest, file, manifest.record(file, gen, names), writeDir)
+			continue
+		}
+
+		single := proto.Clone(req).(*pluginpb.CodeGeneratorRequest)
+		single.FileToGenerate = []string{file}
+		r.Commands = append(r.Commands, generatorCommand(gen, single))
+
+		var resp *pluginpb.CodeGeneratorResponse
+		if gen.builtin != nil {
+			resp, err = gen.builtin(single)
+		} else {
+			resp, err = runPluginBinary(job.ctx, dir, gen.Path, single, func(stream, line string) {
+				job.output(gen.Name, stream, line)
+			})
+		}
+
+		var written []string
+		if err == nil {
+			written, err = writeGeneratorResponse(gen.writeDir(), resp)
+		}
+
+		outputs := []cachedOutput{}
+		for _, name := range written {
+			plugin.Files = append(plugin.Files, name)
+			if sum := r.addFile(gen, name, false); sum != "" {
+				outputs = append(outputs, cachedOutput{Name: name, SHA256: sum})
+			}
+		}
+		if err != nil {
+			break
+		}
+		cache.store(id, keys[file], outputs)
+		r.removeOutputs(manifest, file, manifest.record(file, gen, written), writeDir)
+	}
+
+	plugin.DurationMs = time.Since(start).Milliseconds()
+	switch {
+	case job.ctx.Err() != nil:
+		plugin.Status = StatusCanceled
+		plugin.Message = "canceled"
+	case err != nil:
+		plugin.Status = StatusFailed
+		plugin.Message = err.Error()
+	}
+	r.Plugins = append(r.Plugins, plugin)
+	job.progress(StepPluginFinish, gen.Name, plugin.Status, "%s %s: %d generated, %d reused", gen.Name, plugin.Status, len(plugin.Files), len(plugin.Reused))
+	return err
+}
+
+// unavailableGenerator records a pipeline step that could not be resolved.
+// Optional plugins are skipped, required ones fail.
+func (r *GenerateResult) unavailableGenerator(p PluginConfig, err error) {
+	status := StatusFailed
+	if p.Optional {
+		status = StatusSkipped
+	}
+	r.Plugins = append(r.Plugins, PluginResult{
+		Name:    "protoc-gen-" + p.Name,
+		Path:    p.Path,
+		Status:  status,
+		Message: err.Error(),
+		Files:   []string{},
+		Reused:  []string{},
+	})
+}
+
+// addFile records an output file of gen with its size and content hash,
+// which it returns. reused marks outputs kept from a cached generation.
+func (r *GenerateResult) addFile(gen *generator, name string, reused bool) string {
+	content, err := os.ReadFile(filepath.Join(gen.writeDir(), filepath.FromSlash(name)))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(content)
+	r.Files = append(r.Files, GeneratedFile{
+		Name:   name,
+		Out:    gen.OutDir,
+		Plugin: gen.Name,
+		Size:   int64(len(content)),
+		SHA256: hex.EncodeToString(sum[:]),
+		Reused: reused,
+	})
+	if reused {
+		r.Reused++
+	} else {
+		r.Regenerated++
+	}
+	return hex.EncodeToString(sum[:])
+}
+
+// generatorCommand renders a generator invocation as a protoc-style command line
+func generatorCommand(gen *generator, req *pluginpb.CodeGeneratorRequest) string {
+	flag := strings.TrimPrefix(gen.Name, "protoc-gen-")
+	args := []string{fmt.Sprintf("--%s_out=%s", flag, gen.OutDir)}
+	if req.GetParameter() != "" {
+		args = append(args, fmt.Sprintf("--%s_opt=%s", flag, req.GetParameter()))
+	}
+	args = append(args, req.GetFileToGenerate()...)
+	return fmt.Sprintf("%s %s", gen.Path, strings.Join(args, " "))
+}
+
+// GenerateGRPC saves the proto at filename as SavePB does, checking that
+// it did not change on disk since version, and runs the workspace
+// generation pipeline over the protos of scope: the saved file, the file
+// and its dependents, or the whole workspace. An empty scope means
+// ScopeFile.
+// The run is queued as a generation job like StartGeneration, but the
+// call waits for its result.
+func (a *App) GenerateGRPC(filename, content, version, scope string) *GenerateResult {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return newGenerateResult(filename).fail("Error: %v", err).finish(time.Now())
+	}
+
+	job := a.submitGeneration(ws, filename, content, version, scope)
+	<-job.done
+	return job.result
+}
+
+// runGeneration is the body of a generation job
+func runGeneration(job *generateJob) *GenerateResult {
+	start := time.Now()
+	ws, filename, content, version, scope := job.ws, job.File, job.content, job.version, job.Scope
+	result := newGenerateResult(filename)
+	result.Scope = scope
+	result.OutputDir = ws.OutputPath()
+
+	// First save the protobuf file, unless it changed on disk since the
+	// version the content is based on
+	job.progress(StepSave, "", "", "Saving %s", filename)
+	saved, err := job.save(filename, content, version)
+	if err != nil {
+		return result.fail("Error: %v", err).finish(start)
+	}
+	result.Save = saved
+	if !saved.Saved {
+		return result.fail("%s", saved.Message).finish(start)
+	}
+	result.Version = saved.Version
+	filename = cleanProtoPath(filename)
+	result.File = filename
+
+	// Pick the protos of the scope
+	var protos []string
+	switch scope {
+	case ScopeFile:
+		protos = []string{filename}
+	case ScopeDependents:
+		dependents, err := ws.Dependents(filename)
+		if err != nil {
+			return result.fail("Error finding dependents of %s: %v", filename, err).finish(start)
+		}
+		protos = append([]string{filename}, dependents...)
+	case ScopeWorkspace:
+		if protos, err = ws.ProtoFiles(); err != nil {
+			return result.fail("Error finding proto files: %v", err).finish(start)
+		}
+	default:
+		return result.fail("Error: unknown generation scope %q", scope).finish(start)
+	}
+	result.Protos = protos
+
+	// Parse and link the protos and their imports in process
+	job.progress(StepParse, "", "", "Compiling %s", strings.Join(protos, ", "))
+	diags, compiled := ws.Validate(job.ctx, protos, nil)
+	result.Diagnostics = append(result.Diagnostics, ws.applyLintConfig(diags)...)
+	if compiled == nil {
+		if job.ctx.Err() != nil {
+			result.Status = StatusCanceled
+			return result.finish(start)
+		}
+		return result.fail("Error compiling %s", strings.Join(protos, ", ")).finish(start)
+	}
+
+	// Lint rules set to error stop the generation
+	job.progress(StepLint, "", "", "Linting %s", strings.Join(protos, ", "))
+	lint, err := ws.lintFiles(protos, nil)
+	if err != nil {
+		return result.fail("Error linting: %v", err).finish(start)
+	}
+	result.Diagnostics = append(result.Diagnostics, lint...)
+	if hasErrors(result.Diagnostics) {
+		return result.fail("Lint errors in %s", strings.Join(protos, ", ")).finish(start)
+	}
+	files := linkedDescriptors(compiled)
+
+	// Write into staging copies of the output directories, so a failed or
+	// canceled run leaves the previous output untouched
+	stage, err := stageOutputs(ws, ws.outputDirs(), job.ID)
+	if err != nil {
+		return result.fail("Error preparing output: %v", err).finish(start)
+	}
+
+	// Run the workspace pipeline, each plugin over the same files, skipping
+	// protos whose import closure and plugin are unchanged since last time
+	cache := loadGenerateCache(ws.Root)
+	manifest := loadOutputManifest(ws.Root, cache)
+	requiredFailed := false
+	for _, p := range ws.Plugins() {
+		if job.ctx.Err() != nil {
+			break
+		}
+		gen, err := ws.resolvePlugin(p)
+		if err != nil {
+			result.unavailableGenerator(p, err)
+			job.progress(StepPluginFinish, "protoc-gen-"+p.Name, result.Plugins[len(result.Plugins)-1].Status, "%v", err)
+		} else {
+			gen.stageDir = stage.dir(gen.OutDir)
+			result.runGenerator(job, ws.Root, gen, files, cache, manifest)
+		}
+		if !p.Optional && result.Plugins[len(result.Plugins)-1].Status == StatusFailed {
+			requiredFailed = true
+		}
+	}
+	if job.ctx.Err() != nil {
+		result.Status = StatusCanceled
+	} else {
+		result.Status = pipelineStatus(result.Plugins)
+	}
+
+	// Swap the staged output in only when every required plugin succeeded,
+	// so the output never mixes fresh files with stale ones; the replaced
+	// output is kept as the rollback snapshot
+	if result.Status == StatusCanceled || result.Status == StatusFailed || requiredFailed {
+		stage.discard()
+		result.Discarded = true
+		result.Removed = []RemovedFile{}
+		return result.finish(start)
+	}
+
+	// Remove the outputs of protos deleted or renamed since they were generated
+	if all, err := ws.ProtoFiles(); err == nil {
+		result.removeOrphans(manifest, all, stage.dir)
+	}
+	if err := stage.commit(); err != nil {
+		stage.discard()
+		result.Discarded = true
+		result.Removed = []RemovedFile{}
+		return result.fail("Error replacing output: %v", err).finish(start)
+	}
+	if err := manifest.save(); err != nil {
+		fmt.Printf("Error saving output manifest: %v\n", err)
+	}
+	if err := cache.save(); err != nil {
+		fmt.Printf("Error saving generation cache: %v\n", err)
+	}
+
+	return result.finish(start)
+}
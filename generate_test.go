@@ -0,0 +1,252 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerateGRPC checks the structured result of a generation run
+func TestGenerateGRPC(t *testing.T) {
+	app := NewApp()
+	if _, err := app.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "syntax = \"proto3\";\npackage demo;\noption go_package = \"example.com/demo\";\nmessage Ping { string id = 1; }\n"
+	result := app.GenerateGRPC("demo.proto", content, "", ScopeFile)
+	if result.Status == StatusFailed {
+		t.Fatalf("generation failed: %s", result.Summary)
+	}
+	if len(result.Plugins) == 0 || result.Plugins[0].Status != StatusSuccess {
+		t.Fatalf("built-in protoc-gen-go did not succeed: %+v", result.Plugins)
+	}
+
+	var found *GeneratedFile
+	for i := range result.Files {
+		if result.Files[i].Name == "demo.pb.go" {
+			found = &result.Files[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("demo.pb.go missing from result files: %+v", result.Files)
+	}
+
+	generated, err := os.ReadFile(filepath.Join(result.OutputDir, "demo.pb.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(generated)
+	if found.SHA256 != hex.EncodeToString(sum[:]) || found.Size != int64(len(generated)) {
+		t.Errorf("file entry %+v does not match generated content", found)
+	}
+}
+
+// TestGenerateGRPC_Conflict checks that a run does not overwrite a proto
+// changed on disk since the editor read it, and generates nothing
+func TestGenerateGRPC_Conflict(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto := func(message string) string {
+		return "syntax = \"proto3\";\npackage demo;\noption go_package = \"example.com/demo\";\nmessage " + message + " { string id = 1; }\n"
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{"demo.proto": proto("Read")})
+	file, err := app.ReadPB("demo.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another tool changes the file after the editor read it
+	external := proto("External")
+	writeFiles(t, ws.PBPath(), map[string]string{"demo.proto": external})
+
+	result := app.GenerateGRPC("demo.proto", proto("Edited"), file.Version, ScopeFile)
+	if result.Status != StatusFailed || result.Save == nil || result.Save.Conflict == nil {
+		t.Fatalf("stale generate = %s %+v, want a conflict", result.Status, result.Save)
+	}
+	if result.Save.Conflict.Disk != external || len(result.Plugins) != 0 {
+		t.Errorf("conflict = %+v, plugins = %+v", result.Save.Conflict, result.Plugins)
+	}
+	if disk, _ := os.ReadFile(filepath.Join(ws.PBPath(), "demo.proto")); string(disk) != external {
+		t.Errorf("external edit overwritten with %q", disk)
+	}
+	if _, err := os.Stat(filepath.Join(ws.OutputPath(), "demo.pb.go")); err == nil {
+		t.Error("generated from stale content")
+	}
+
+	// Generating from the version on disk saves and generates
+	result = app.GenerateGRPC("demo.proto", proto("Edited"), result.Save.Conflict.DiskVersion, ScopeFile)
+	if result.Status == StatusFailed || !result.Save.Saved || result.Version != result.Save.Version {
+		t.Fatalf("generate = %s %s", result.Status, result.Summary)
+	}
+	if generated, _ := os.ReadFile(filepath.Join(ws.OutputPath(), "demo.pb.go")); !strings.Contains(string(generated), "type Edited struct") {
+		t.Error("output not generated from the saved content")
+	}
+}
+
+// TestGenerateGRPC_CompileError checks that compile errors surface as diagnostics
+func TestGenerateGRPC_CompileError(t *testing.T) {
+	app := NewApp()
+	if _, err := app.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	result := app.GenerateGRPC("broken.proto", "syntax = \"proto3\";\nmessage A {\n  string id = 1\n}\n", "", ScopeFile)
+	if result.Status != StatusFailed {
+		t.Errorf("status = %s, want %s", result.Status, StatusFailed)
+	}
+	if len(result.Diagnostics) == 0 || result.Diagnostics[0].Line != 4 {
+		t.Errorf("diagnostics = %+v, want a syntax error on line 4", result.Diagnostics)
+	}
+}
+
+// TestGenerateGRPC_Pipeline checks that the configured plugins, output
+// directories and options are used
+func TestGenerateGRPC_Pipeline(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plugins := []PluginConfig{
+		// The M mapping stands in for the missing go_package option
+		{Name: "go", Out: "gen/go", Opt: []string{"paths=source_relative", "Mdemo.proto=example.com/demo;demo"}},
+		{Name: "missing", Path: "protoc-gen-does-not-exist", Optional: true},
+	}
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"plugins": plugins}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := app.GenerateGRPC("demo.proto", "syntax = \"proto3\";\npackage demo;\nmessage Ping { string id = 1; }\n", "", ScopeFile)
+	if result.Status != StatusPartial {
+		t.Fatalf("status = %s, want %s: %s", result.Status, StatusPartial, result.Summary)
+	}
+	if len(result.Plugins) != 2 || result.Plugins[0].Status != StatusSuccess || result.Plugins[1].Status != StatusSkipped {
+		t.Fatalf("plugins = %+v, want go to succeed and the missing plugin to be skipped", result.Plugins)
+	}
+
+	generated, err := os.ReadFile(filepath.Join(ws.Root, "gen", "go", "demo.pb.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(generated), "package demo") {
+		t.Errorf("demo.pb.go does not use the M mapping package")
+	}
+}
+
+// TestGenerateGRPC_Cache checks that unchanged protos reuse their outputs and
+// that a change to an import regenerates its importers
+func TestGenerateGRPC_Cache(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dep := "syntax = \"proto3\";\npackage demo;\noption go_package = \"example.com/demo\";\nmessage Dep { string id = 1; }\n"
+	main := "syntax = \"proto3\";\npackage demo;\noption go_package = \"example.com/demo\";\nimport \"dep.proto\";\nmessage Main { Dep dep = 1; }\n"
+	if err := os.MkdirAll(ws.PBPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ws.PBPath(), "dep.proto"), []byte(dep), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	generate := func(file, content, scope string) (regenerated, reused []string) {
+		t.Helper()
+		result := app.GenerateGRPC(file, content, "", scope)
+		if result.Status == StatusFailed {
+			t.Fatalf("generation failed: %s", result.Summary)
+		}
+		for _, f := range result.Files {
+			if f.Reused {
+				reused = append(reused, f.Name)
+			} else {
+				regenerated = append(regenerated, f.Name)
+			}
+		}
+		return regenerated, reused
+	}
+
+	if regenerated, _ := generate("main.proto", main, ScopeWorkspace); len(regenerated) != 2 {
+		t.Fatalf("first run regenerated %v, want both protos", regenerated)
+	}
+	if regenerated, reused := generate("main.proto", main, ScopeWorkspace); len(regenerated) != 0 || len(reused) != 2 {
+		t.Errorf("unchanged run regenerated %v and reused %v, want everything reused", regenerated, reused)
+	}
+
+	// Changing the import invalidates its importer too
+	if regenerated, _ := generate("dep.proto", strings.Replace(dep, "string id = 1;", "string id = 1;\n  int32 rev = 2;", 1), ScopeFile); strings.Join(regenerated, ",") != "dep.pb.go" {
+		t.Errorf("file scope regenerated %v, want only dep.pb.go", regenerated)
+	}
+	if regenerated, reused := generate("main.proto", main, ScopeWorkspace); strings.Join(regenerated, ",") != "main.pb.go" || strings.Join(reused, ",") != "dep.pb.go" {
+		t.Errorf("after changing dep.proto regenerated %v and reused %v", regenerated, reused)
+	}
+
+	// Deleted outputs are regenerated
+	if err := os.Remove(filepath.Join(ws.OutputPath(), "main.pb.go")); err != nil {
+		t.Fatal(err)
+	}
+	if regenerated, _ := generate("main.proto", main, ScopeFile); strings.Join(regenerated, ",") != "main.pb.go" {
+		t.Errorf("after deleting main.pb.go regenerated %v", regenerated)
+	}
+}
+
+// TestGenerateGRPC_Scope checks which protos each scope generates
+func TestGenerateGRPC_Scope(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := "syntax = \"proto3\";\npackage demo;\noption go_package = \"example.com/demo\";\n"
+	protos := map[string]string{
+		"base.proto":   header + "message Base {}\n",
+		"middle.proto": header + "import \"base.proto\";\nmessage Middle { Base base = 1; }\n",
+		"top.proto":    header + "import \"middle.proto\";\nmessage Top { Middle middle = 1; }\n",
+		"other.proto":  header + "message Other {}\n",
+	}
+	if err := os.MkdirAll(ws.PBPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range protos {
+		if err := os.WriteFile(filepath.Join(ws.PBPath(), name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		scope string
+		want  string
+	}{
+		{ScopeFile, "base.proto"},
+		{ScopeDependents, "base.proto,middle.proto,top.proto"},
+		{ScopeWorkspace, "base.proto,middle.proto,other.proto,top.proto"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			result := app.GenerateGRPC("base.proto", protos["base.proto"], "", tt.scope)
+			if result.Status == StatusFailed {
+				t.Fatalf("generation failed: %s", result.Summary)
+			}
+			if got := strings.Join(result.Protos, ","); got != tt.want {
+				t.Errorf("protos = %s, want %s", got, tt.want)
+			}
+			if len(result.Files) != len(result.Protos) {
+				t.Errorf("files = %+v, want one per proto", result.Files)
+			}
+		})
+	}
+
+	if result := app.GenerateGRPC("base.proto", protos["base.proto"], "", "bogus"); result.Status != StatusFailed {
+		t.Errorf("unknown scope status = %s, want %s", result.Status, StatusFailed)
+	}
+}
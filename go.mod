@@ -5,14 +5,23 @@ go 1.24.0
 toolchain go1.24.5
 
 require (
+	github.com/bufbuild/protocompile v0.14.1
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/grpc-ecosystem/grpc-gateway/v2 v2.27.4
 	github.com/wailsapp/wails/v2 v2.11.0
 	golang.org/x/crypto v0.44.0
 	google.golang.org/genproto/googleapis/api v0.0.0-20251222181119-0a764e51fe1b
 	google.golang.org/grpc v1.78.0
 	google.golang.org/protobuf v1.36.11
+	gopkg.in/yaml.v3 v3.0.1
 )
 
+// codegen.go runs protoc-gen-go in process through its internal_gengo
+// package, which has no compatibility promise. The replace keeps upgrades
+// of other modules from raising protobuf-go past the version it was built
+// against; bump it deliberately and rerun TestGenerateGoBuiltin.
+replace google.golang.org/protobuf => google.golang.org/protobuf v1.36.11
+
 require (
 	github.com/bep/debounce v1.2.1 // indirect
 	github.com/go-ole/go-ole v1.3.0 // indirect
@@ -38,6 +47,7 @@ require (
 	github.com/wailsapp/go-webview2 v1.0.22 // indirect
 	github.com/wailsapp/mimetype v1.4.1 // indirect
 	golang.org/x/net v0.47.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20251222181119-0a764e51fe1b // indirect
@@ -0,0 +1,84 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: custom_options.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var file_custom_options_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*bool)(nil),
+		Field:         10001,
+		Name:          "example.publish",
+		Tag:           "varint,10001,opt,name=publish",
+		Filename:      "custom_options.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// 标记接口是否可以被内部服务请求，默认为true
+	//
+	// optional bool publish = 10001;
+	E_Publish = &file_custom_options_proto_extTypes[0]
+)
+
+var File_custom_options_proto protoreflect.FileDescriptor
+
+const file_custom_options_proto_rawDesc = "" +
+	"\n" +
+	"\x14custom_options.proto\x12\aexample\x1a google/protobuf/descriptor.proto:9\n" +
+	"\apublish\x12\x1e.google.protobuf.MethodOptions\x18\x91N \x01(\bR\apublishB\x18Z\x16pb-tool/grpc_output/pbb\x06proto3"
+
+var file_custom_options_proto_goTypes = []any{
+	(*descriptorpb.MethodOptions)(nil), // 0: google.protobuf.MethodOptions
+}
+var file_custom_options_proto_depIdxs = []int32{
+	0, // 0: example.publish:extendee -> google.protobuf.MethodOptions
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_custom_options_proto_init() }
+func file_custom_options_proto_init() {
+	if File_custom_options_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_custom_options_proto_rawDesc), len(file_custom_options_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_custom_options_proto_goTypes,
+		DependencyIndexes: file_custom_options_proto_depIdxs,
+		ExtensionInfos:    file_custom_options_proto_extTypes,
+	}.Build()
+	File_custom_options_proto = out.File
+	file_custom_options_proto_goTypes = nil
+	file_custom_options_proto_depIdxs = nil
+}
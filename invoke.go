@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// invokeTimeout bounds RPCs invoked without a timeout
+const invokeTimeout = 30 * time.Second
+
+// InvokeRequest describes an RPC to call. Method is the full name of the
+// RPC, as package.Service/Method, /package.Service/Method or
+// package.Service.Method; Body is the request message as JSON. The RPC is
+// looked up in the workspace protos, or in those of the server by gRPC
+// reflection when Reflection is set.
+type InvokeRequest struct {
+	Target     string            `json:"target"`
+	Method     string            `json:"method"`
+	Body       string            `json:"body"`
+	Metadata   map[string]string `json:"metadata"`
+	TLS        bool              `json:"tls"`
+	TimeoutMs  int64             `json:"timeoutMs"`
+	Reflection bool              `json:"reflection"`
+}
+
+// InvokeResult is the outcome of an RPC. Code is the gRPC status code
+// name; Response is the response message as JSON, empty when the RPC
+// failed.
+type InvokeResult struct {
+	Method    string              `json:"method"`
+	Response  string              `json:"response"`
+	Headers   map[string][]string `json:"headers"`
+	Trailers  map[string][]string `json:"trailers"`
+	Code      string              `json:"code"`
+	Message   string              `json:"message,omitempty"`
+	LatencyMs int64               `json:"latencyMs"`
+}
+
+// fullMethodName returns the name of an RPC as used on the wire
+func fullMethodName(m protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", m.Parent().FullName(), m.Name())
+}
+
+// findMethod finds an RPC by its full name among files
+func findMethod(files typeResolver, name string) (protoreflect.MethodDescriptor, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+	if name == "" {
+		return nil, errors.New("no method given")
+	}
+	name = strings.Replace(name, "/", ".", 1)
+	d, err := files.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("method %s not found", name)
+	}
+	m, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a method", name)
+	}
+	return m, nil
+}
+
+// workspaceTypes compiles the workspace and returns its types
+func (a *App) workspaceTypes(ctx context.Context) (typeResolver, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return typeResolver{}, err
+	}
+	compiled, err := ws.CompileAll(ctx)
+	if err != nil {
+		return typeResolver{}, err
+	}
+	return typeResolver{files: compiled.AsResolver()}, nil
+}
+
+// dial connects to an RPC target, in plaintext unless useTLS is set
+func dial(target string, useTLS bool) (*grpc.ClientConn, error) {
+	if strings.TrimSpace(target) == "" {
+		return nil, errors.New("no target given")
+	}
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	return grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+}
+
+// newMessage parses a JSON message of type md; empty JSON is the empty
+// message
+func newMessage(md protoreflect.MessageDescriptor, body string, types typeResolver) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(md)
+	if strings.TrimSpace(body) == "" {
+		return msg, nil
+	}
+	if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal([]byte(body), msg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", md.FullName(), err)
+	}
+	return msg, nil
+}
+
+// messageJSON formats a message as indented JSON, with unset fields
+func messageJSON(msg proto.Message, types typeResolver) string {
+	data, err := protojson.MarshalOptions{Multiline: true, Indent: "  ", EmitUnpopulated: true, Resolver: types}.Marshal(msg)
+	if err != nil {
+		return fmt.Sprintf("formatting response: %v", err)
+	}
+	return string(data)
+}
+
+// outgoingContext adds the request metadata to ctx and bounds it by the
+// request timeout, or by fallback when the request has none. A zero
+// fallback leaves it unbounded.
+func outgoingContext(ctx context.Context, req InvokeRequest, fallback time.Duration) (context.Context, context.CancelFunc) {
+	if len(req.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(req.Metadata))
+	}
+	timeout := fallback
+	if req.TimeoutMs > 0 {
+		timeout = time.Duration(req.TimeoutMs) * time.Millisecond
+	}
+	if timeout == 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// metadataMap returns md as a non-nil map
+func metadataMap(md metadata.MD) map[string][]string {
+	if md == nil {
+		return map[string][]string{}
+	}
+	return md
+}
+
+// invoke calls a unary RPC. Errors are returned for requests that cannot
+// be sent; failed RPCs are reported in the result.
+func invoke(ctx context.Context, types typeResolver, req InvokeRequest) (*InvokeResult, error) {
+	method, err := findMethod(types, req.Method)
+	if err != nil {
+		return nil, err
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("%s is a %s RPC, start a stream to call it", method.FullName(), streamingKind(method))
+	}
+	in, err := newMessage(method.Input(), req.Body, types)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dial(req.Target, req.TLS)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := outgoingContext(ctx, req, invokeTimeout)
+	defer cancel()
+
+	result := &InvokeResult{Method: fullMethodName(method)}
+	var headers, trailers metadata.MD
+	out := dynamicpb.NewMessage(method.Output())
+	start := time.Now()
+	err = conn.Invoke(ctx, result.Method, in, out, grpc.Header(&headers), grpc.Trailer(&trailers))
+	result.LatencyMs = time.Since(start).Milliseconds()
+
+	st := status.Convert(err)
+	result.Code = st.Code().String()
+	result.Message = st.Message()
+	result.Headers = metadataMap(headers)
+	result.Trailers = metadataMap(trailers)
+	if err == nil {
+		result.Response = messageJSON(out, types)
+	}
+	return result, nil
+}
+
+// InvokeRPC calls a unary RPC on a running server
+func (a *App) InvokeRPC(req InvokeRequest) (*InvokeResult, error) {
+	types, err := a.requestTypes(context.Background(), req)
+	if err != nil {
+		return nil, err
+	}
+	return invoke(context.Background(), types, req)
+}
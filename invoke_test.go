@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"pb-tool/grpc_output/pb"
+	example "pb-tool/pb"
+)
+
+// openExampleWorkspace opens a workspace with the bundled example protos
+func openExampleWorkspace(t *testing.T, app *App) *Workspace {
+	t.Helper()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"example.proto", "custom_options.proto"} {
+		content, err := os.ReadFile(filepath.Join("pb", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, ws.PBPath(), map[string]string{name: string(content)})
+	}
+	return ws
+}
+
+// serveExample starts the example server and returns its address
+func serveExample(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(grpc.UnaryInterceptor(example.PublishInterceptor()))
+	pb.RegisterExampleServiceServer(s, &example.ExampleServer{})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+// TestInvokeRPC calls the example server with JSON requests and metadata
+func TestInvokeRPC(t *testing.T) {
+	app := NewApp()
+	openExampleWorkspace(t, app)
+	target := serveExample(t)
+
+	result, err := app.InvokeRPC(InvokeRequest{Target: target, Method: "example.ExampleService/GetExample", Body: `{"id": "7"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal([]byte(result.Response), &response); err != nil {
+		t.Fatalf("response %q: %v", result.Response, err)
+	}
+	want := map[string]interface{}{"id": "7", "name": "Example", "value": float64(123)}
+	if result.Code != "OK" || result.Method != "/example.ExampleService/GetExample" || !reflect.DeepEqual(response, want) {
+		t.Errorf("GetExample = %+v", result)
+	}
+	if got := result.Headers["content-type"]; len(got) != 1 || got[0] != "application/grpc" {
+		t.Errorf("headers = %v", result.Headers)
+	}
+
+	// The interceptor rejects internal requests to unpublished methods
+	result, err = app.InvokeRPC(InvokeRequest{
+		Target:   target,
+		Method:   "/example.ExampleService/CreateExample",
+		Body:     `{"id": "1"}`,
+		Metadata: map[string]string{"x-internal-service": "orders"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Code != "PermissionDenied" || result.Response != "" || result.Message == "" {
+		t.Errorf("internal CreateExample = %+v", result)
+	}
+
+	for _, req := range []InvokeRequest{
+		{Target: target, Method: "example.ExampleService.Missing"},
+		{Target: target, Method: "example.Example"},
+		{Target: target, Method: "example.ExampleService.GetExample", Body: `{"unknown": 1}`},
+		{Method: "example.ExampleService.GetExample"},
+	} {
+		if _, err := app.InvokeRPC(req); err == nil {
+			t.Errorf("InvokeRPC(%+v) succeeded", req)
+		}
+	}
+}
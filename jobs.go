@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Generation job events sent to the frontend
+const (
+	// EventGenerateProgress carries a GenerateEvent for every step of a job
+	EventGenerateProgress = "generate:progress"
+	// EventGenerateDone carries the GenerateDone of a finished job
+	EventGenerateDone = "generate:done"
+	// EventGenerateJobs carries the GenerationJob list whenever it changes
+	EventGenerateJobs = "generate:jobs"
+)
+
+// Generation job steps reported in progress events
+const (
+	StepSave         = "save"
+	StepParse        = "parse"
+	StepLint         = "lint"
+	StepPluginStart  = "plugin_start"
+	StepPluginFinish = "plugin_finish"
+	StepOutput       = "output"
+	StepDone         = "done"
+)
+
+// Generation job states
+const (
+	JobQueued   = "queued"
+	JobRunning  = "running"
+	JobFinished = "finished"
+)
+
+// maxJobHistory bounds the finished jobs kept for the frontend
+const maxJobHistory = 50
+
+// GenerateEvent is a progress event of a generation job. Stream is stdout
+// or stderr for plugin output lines.
+type GenerateEvent struct {
+	JobID   string    `json:"jobId"`
+	Step    string    `json:"step"`
+	Plugin  string    `json:"plugin,omitempty"`
+	Stream  string    `json:"stream,omitempty"`
+	Status  string    `json:"status,omitempty"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// GenerateDone is the event sent when a generation job finishes
+type GenerateDone struct {
+	JobID  string          `json:"jobId"`
+	Result *GenerateResult `json:"result"`
+}
+
+// GenerationJob describes a queued, running or finished generation job.
+// Coalesced counts the later requests merged into the job while it was
+// queued. Events and Result are only filled in by GetGenerationJob.
+type GenerationJob struct {
+	ID         string          `json:"id"`
+	Workspace  string          `json:"workspace"`
+	File       string          `json:"file"`
+	Scope      string          `json:"scope"`
+	State      string          `json:"state"`
+	Status     string          `json:"status,omitempty"`
+	Coalesced  int             `json:"coalesced"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	StartedAt  time.Time       `json:"startedAt"`
+	FinishedAt time.Time       `json:"finishedAt"`
+	Events     []GenerateEvent `json:"events,omitempty"`
+	Result     *GenerateResult `json:"result,omitempty"`
+}
+
+// generateJob is a generation request on its way through the scheduler.
+// Canceling its context kills the plugin process it is waiting on. The
+// scheduling fields are guarded by App.jobsMu, events and result by mu.
+type generateJob struct {
+	ID     string
+	File   string
+	Scope  string
+	ws     *Workspace
+	ctx    context.Context
+	cancel context.CancelFunc
+	emit   func(name string, data interface{})
+	done   chan struct{}
+	// save writes the proto of the job as SavePB does
+	save func(filename, content, version string) (*SaveResult, error)
+
+	content    string
+	version    string
+	state      string
+	coalesced  int
+	createdAt  time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+
+	mu     sync.Mutex
+	events []GenerateEvent
+	result *GenerateResult
+}
+
+// record stores a progress event of the job and sends it to the frontend
+func (j *generateJob) record(event GenerateEvent) {
+	event.JobID = j.ID
+	event.Time = time.Now()
+	j.mu.Lock()
+	j.events = append(j.events, event)
+	j.mu.Unlock()
+	j.emit(EventGenerateProgress, event)
+}
+
+// progress records a step of the job
+func (j *generateJob) progress(step, plugin, status, format string, args ...interface{}) {
+	j.record(GenerateEvent{Step: step, Plugin: plugin, Status: status, Message: fmt.Sprintf(format, args...)})
+}
+
+// output records a line a plugin wrote to stream
+func (j *generateJob) output(plugin, stream, line string) {
+	j.record(GenerateEvent{Step: StepOutput, Plugin: plugin, Stream: stream, Message: line})
+}
+
+// info describes the job; the caller holds App.jobsMu
+func (j *generateJob) info(detailed bool) GenerationJob {
+	info := GenerationJob{
+		ID:         j.ID,
+		Workspace:  j.ws.Root,
+		File:       j.File,
+		Scope:      j.Scope,
+		State:      j.state,
+		Coalesced:  j.coalesced,
+		CreatedAt:  j.createdAt,
+		StartedAt:  j.startedAt,
+		FinishedAt: j.finishedAt,
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.result != nil {
+		info.Status = j.result.Status
+	}
+	if detailed {
+		info.Events = append([]GenerateEvent{}, j.events...)
+		info.Result = j.result
+	}
+	return info
+}
+
+// emit sends a runtime event to the frontend. It does nothing before the
+// Wails runtime has started, such as in tests.
+func (a *App) emit(name string, data interface{}) {
+	if a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, name, data)
+}
+
+// submitGeneration queues a generation of filename in ws. Jobs of one
+// workspace run one at a time, jobs of different workspaces in parallel.
+// A request for the same file and scope as a job still waiting in the
+// queue is merged into it, with the newer content, and that job returned.
+func (a *App) submitGeneration(ws *Workspace, filename, content, version, scope string) *generateJob {
+	if scope == "" {
+		scope = ScopeFile
+	}
+	filename = cleanProtoPath(filename)
+
+	a.jobsMu.Lock()
+	for _, queued := range a.jobQueues[ws.Root] {
+		if queued.File == filename && queued.Scope == scope {
+			queued.content = content
+			queued.version = version
+			queued.coalesced++
+			a.jobsMu.Unlock()
+			a.emitJobs()
+			return queued
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	save := func(filename, content, version string) (*SaveResult, error) {
+		return a.savePB(ws, filename, content, version)
+	}
+	job := &generateJob{
+		ID:        a.generateID(),
+		File:      filename,
+		Scope:     scope,
+		ws:        ws,
+		ctx:       ctx,
+		cancel:    cancel,
+		emit:      a.emit,
+		save:      save,
+		done:      make(chan struct{}),
+		content:   content,
+		version:   version,
+		state:     JobQueued,
+		createdAt: time.Now(),
+	}
+	a.jobs[job.ID] = job
+	a.jobQueues[ws.Root] = append(a.jobQueues[ws.Root], job)
+
+	// Start a runner for the workspace unless one is already draining its queue
+	start := !a.jobRunners[ws.Root]
+	a.jobRunners[ws.Root] = true
+	a.jobsMu.Unlock()
+
+	if start {
+		go a.runWorkspaceJobs(ws.Root)
+	}
+	a.emitJobs()
+	return job
+}
+
+// runWorkspaceJobs runs the queued jobs of a workspace in order until its
+// queue is empty
+func (a *App) runWorkspaceJobs(root string) {
+	for {
+		a.jobsMu.Lock()
+		queue := a.jobQueues[root]
+		if len(queue) == 0 {
+			delete(a.jobQueues, root)
+			delete(a.jobRunners, root)
+			a.jobsMu.Unlock()
+			return
+		}
+		job := queue[0]
+		a.jobQueues[root] = queue[1:]
+		job.state = JobRunning
+		job.startedAt = time.Now()
+		a.jobsMu.Unlock()
+		a.emitJobs()
+
+		a.finishJob(job, runGeneration(job))
+	}
+}
+
+// finishJob moves job to the history and announces its result
+func (a *App) finishJob(job *generateJob, result *GenerateResult) {
+	job.cancel()
+	result.JobID = job.ID
+
+	job.mu.Lock()
+	job.result = result
+	job.mu.Unlock()
+	job.progress(StepDone, "", result.Status, "Generation %s", result.Status)
+
+	a.jobsMu.Lock()
+	job.state = JobFinished
+	job.finishedAt = time.Now()
+	delete(a.jobs, job.ID)
+	a.jobHistory = append([]*generateJob{job}, a.jobHistory...)
+	if len(a.jobHistory) > maxJobHistory {
+		a.jobHistory = a.jobHistory[:maxJobHistory]
+	}
+	a.jobsMu.Unlock()
+
+	a.emit(EventGenerateDone, GenerateDone{JobID: job.ID, Result: result})
+	a.emitJobs()
+	close(job.done)
+}
+
+// emitJobs sends the job list to the frontend
+func (a *App) emitJobs() {
+	if a.ctx == nil {
+		return
+	}
+	a.emit(EventGenerateJobs, a.GetGenerationJobs())
+}
+
+// StartGeneration queues GenerateGRPC as a background job and returns its
+// ID at once. Progress is reported with generate:progress events and the
+// result with a generate:done event. The ID of a queued job is returned
+// when the request was merged into it.
+func (a *App) StartGeneration(filename, content, version, scope string) (string, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return "", err
+	}
+	return a.submitGeneration(ws, filename, content, version, scope).ID, nil
+}
+
+// CancelGeneration stops a generation job. A queued job is dropped, a
+// running one has the plugin process it is waiting on killed.
+func (a *App) CancelGeneration(jobID string) error {
+	a.jobsMu.Lock()
+	job, ok := a.jobs[jobID]
+	if !ok {
+		a.jobsMu.Unlock()
+		return fmt.Errorf("no queued or running generation job %s", jobID)
+	}
+
+	queued := job.state == JobQueued
+	if queued {
+		queue := a.jobQueues[job.ws.Root]
+		for i, j := range queue {
+			if j == job {
+				a.jobQueues[job.ws.Root] = append(queue[:i:i], queue[i+1:]...)
+				break
+			}
+		}
+	}
+	a.jobsMu.Unlock()
+
+	job.cancel()
+	if queued {
+		result := newGenerateResult(job.File)
+		result.Scope = job.Scope
+		result.Status = StatusCanceled
+		a.finishJob(job, result.finish(time.Now()))
+	}
+	return nil
+}
+
+// GetGenerationJobs lists the running and queued generation jobs followed
+// by the finished ones, newest first
+func (a *App) GetGenerationJobs() []GenerationJob {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+
+	jobs := []GenerationJob{}
+	for _, queue := range a.sortedJobQueues() {
+		for _, job := range queue {
+			jobs = append(jobs, job.info(false))
+		}
+	}
+	for _, job := range a.jobHistory {
+		jobs = append(jobs, job.info(false))
+	}
+	return jobs
+}
+
+// sortedJobQueues returns the active jobs grouped by workspace, the running
+// job of each workspace first; the caller holds jobsMu
+func (a *App) sortedJobQueues() [][]*generateJob {
+	byRoot := make(map[string][]*generateJob)
+	var roots []string
+	for _, job := range a.jobs {
+		if _, ok := byRoot[job.ws.Root]; !ok {
+			roots = append(roots, job.ws.Root)
+		}
+		byRoot[job.ws.Root] = append(byRoot[job.ws.Root], job)
+	}
+	sort.Strings(roots)
+
+	queues := make([][]*generateJob, 0, len(roots))
+	for _, root := range roots {
+		jobs := byRoot[root]
+		sort.Slice(jobs, func(i, j int) bool {
+			if (jobs[i].state == JobRunning) != (jobs[j].state == JobRunning) {
+				return jobs[i].state == JobRunning
+			}
+			return jobs[i].createdAt.Before(jobs[j].createdAt)
+		})
+		queues = append(queues, jobs)
+	}
+	return queues
+}
+
+// GetGenerationJob returns a job with its progress events and result
+func (a *App) GetGenerationJob(jobID string) (*GenerationJob, error) {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+
+	job, ok := a.jobs[jobID]
+	if !ok {
+		for _, finished := range a.jobHistory {
+			if finished.ID == jobID {
+				job, ok = finished, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown generation job %s", jobID)
+	}
+	info := job.info(true)
+	return &info, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestStartGeneration_Cancel checks progress events, plugin stderr streaming
+// and cancellation of a running plugin
+func TestStartGeneration_Cancel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script plugin")
+	}
+
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := filepath.Join(ws.Root, "protoc-gen-slow")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho working >&2\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"plugins": []PluginConfig{{Name: "slow", Path: script}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	jobID, err := app.StartGeneration("demo.proto", "syntax = \"proto3\";\npackage demo;\n", "", ScopeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.jobsMu.Lock()
+	job := app.jobs[jobID]
+	app.jobsMu.Unlock()
+	if job == nil {
+		t.Fatal("job not registered")
+	}
+
+	// Wait for the plugin's stderr line before canceling
+	deadline := time.Now().Add(10 * time.Second)
+	for !hasEvent(job, StepOutput, "working") {
+		if time.Now().After(deadline) {
+			t.Fatal("no stderr output event from the plugin")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := app.CancelGeneration(jobID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-job.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("canceled job did not finish")
+	}
+
+	if job.result.Status != StatusCanceled || job.result.JobID != jobID {
+		t.Errorf("result status = %s, job = %s, want %s for %s", job.result.Status, job.result.JobID, StatusCanceled, jobID)
+	}
+	for _, step := range []string{StepSave, StepParse, StepPluginStart, StepPluginFinish, StepDone} {
+		if !hasEvent(job, step, "") {
+			t.Errorf("missing %s event", step)
+		}
+	}
+	if err := app.CancelGeneration(jobID); err == nil {
+		t.Error("canceling a finished job should fail")
+	}
+}
+
+// hasEvent reports whether job recorded an event of step, with message when set
+func hasEvent(job *generateJob, step, message string) bool {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	for _, e := range job.events {
+		if e.Step == step && (message == "" || e.Message == message) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestGenerationScheduler checks that jobs are serialized per workspace, run
+// in parallel across workspaces and that queued duplicates are merged
+func TestGenerationScheduler(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script plugin")
+	}
+
+	app := NewApp()
+	blocked, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The plugin of the blocked workspace waits for a release file in its root
+	script := filepath.Join(blocked.Root, "protoc-gen-wait")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nwhile [ ! -f release ]; do sleep 0.05; done\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	blocked.Config.Plugins = []PluginConfig{{Name: "wait", Path: script}}
+
+	proto := func(msg string) string {
+		return "syntax = \"proto3\";\npackage demo;\noption go_package = \"example.com/demo\";\nmessage " + msg + " {}\n"
+	}
+	wait := func(job *generateJob) {
+		t.Helper()
+		select {
+		case <-job.done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("job %s for %s did not finish", job.ID, job.File)
+		}
+	}
+
+	first := app.submitGeneration(blocked, "a.proto", proto("A"), "", ScopeFile)
+	queued := app.submitGeneration(blocked, "b.proto", proto("Old"), "", ScopeFile)
+	merged := app.submitGeneration(blocked, "b.proto", proto("New"), "", "")
+	if merged != queued {
+		t.Fatal("duplicate queued request was not merged")
+	}
+
+	// Another workspace is not held up by the blocked one
+	parallel := app.submitGeneration(other, "c.proto", proto("C"), "", ScopeFile)
+	wait(parallel)
+	if parallel.result.Status == StatusFailed {
+		t.Errorf("parallel job status = %s: %s", parallel.result.Status, parallel.result.Summary)
+	}
+
+	// The runner picks up the first job asynchronously
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		info, err := app.GetGenerationJob(first.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.State == JobRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("first job is still %s", info.State)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	states := make(map[string]string)
+	for _, job := range app.GetGenerationJobs() {
+		states[job.ID] = job.State
+	}
+	if states[first.ID] != JobRunning || states[queued.ID] != JobQueued || states[parallel.ID] != JobFinished {
+		t.Errorf("job states = %v", states)
+	}
+
+	if err := os.WriteFile(filepath.Join(blocked.Root, "release"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	wait(queued)
+
+	if queued.startedAt.Before(first.finishedAt) {
+		t.Error("jobs of one workspace overlapped")
+	}
+	saved, err := os.ReadFile(filepath.Join(blocked.PBPath(), "b.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(saved) != proto("New") {
+		t.Errorf("merged job saved %q, want the newer content", saved)
+	}
+
+	info, err := app.GetGenerationJob(queued.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Coalesced != 1 || info.Result == nil || len(info.Events) == 0 {
+		t.Errorf("job info = %+v, want one merged request, a result and events", info)
+	}
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// Lint rule IDs, used as the code of their diagnostics
+const (
+	LintMessagePascalCase   = "MESSAGE_PASCAL_CASE"
+	LintFieldSnakeCase      = "FIELD_SNAKE_CASE"
+	LintRPCRequestSuffix    = "RPC_REQUEST_SUFFIX"
+	LintRPCResponseSuffix   = "RPC_RESPONSE_SUFFIX"
+	LintRPCComment          = "RPC_COMMENT"
+	LintGoPackage           = "GO_PACKAGE"
+	LintUnusedImport        = CodeUnusedImport
+	LintEnumZeroUnspecified = "ENUM_ZERO_UNSPECIFIED"
+	LintPackageDirectory    = "PACKAGE_DIRECTORY_MATCH"
+)
+
+// LintOff disables a rule in the workspace lint configuration
+const LintOff = "off"
+
+// LintRule is a lint check with its severity in the current workspace
+type LintRule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+}
+
+// lintRules lists every rule, in the order they are shown
+var lintRules = []LintRule{
+	{ID: LintMessagePascalCase, Description: "Message names are PascalCase"},
+	{ID: LintFieldSnakeCase, Description: "Field names are lower_snake_case"},
+	{ID: LintRPCRequestSuffix, Description: "RPC request types end in Request"},
+	{ID: LintRPCResponseSuffix, Description: "RPC response types end in Response"},
+	{ID: LintRPCComment, Description: "RPCs have a leading comment"},
+	{ID: LintGoPackage, Description: "Files set the go_package option"},
+	{ID: LintUnusedImport, Description: "Imports are used"},
+	{ID: LintEnumZeroUnspecified, Description: "The zero value of enums ends in _UNSPECIFIED"},
+	{ID: LintPackageDirectory, Description: "The package matches the directory of the file"},
+}
+
+var (
+	pascalCasePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	snakeCasePattern  = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+)
+
+// emptyMessage is allowed as the request and response type of any RPC
+const emptyMessage = "google.protobuf.Empty"
+
+// lintSeverity returns the configured severity of a rule, warning unless
+// the workspace sets it to error or off
+func (w *Workspace) lintSeverity(rule string) string {
+	switch severity := w.Config.Lint[rule]; severity {
+	case SeverityError, SeverityWarning, LintOff:
+		return severity
+	}
+	return SeverityWarning
+}
+
+// isLintRule reports whether id names a lint rule
+func isLintRule(id string) bool {
+	for _, rule := range lintRules {
+		if rule.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// applyLintConfig gives the compiler diagnostics that are lint findings,
+// such as unused imports, their configured severity, dropping those of
+// disabled rules. Other diagnostics are kept as they are.
+func (w *Workspace) applyLintConfig(diags []Diagnostic) []Diagnostic {
+	kept := diags[:0]
+	for _, d := range diags {
+		if isLintRule(d.Code) {
+			if d.Severity = w.lintSeverity(d.Code); d.Severity == LintOff {
+				continue
+			}
+		}
+		kept = append(kept, d)
+	}
+	return kept
+}
+
+// Lint checks files against the lint rules of the workspace. overlay maps
+// pb-relative paths to unsaved content. Files that do not parse report
+// their syntax error instead.
+func (w *Workspace) Lint(ctx context.Context, files []string, overlay map[string]string) ([]Diagnostic, error) {
+	diags, err := w.lintFiles(files, overlay)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unused imports need the imports linked
+	if w.lintSeverity(LintUnusedImport) != LintOff {
+		compiled, _ := w.Validate(ctx, files, overlay)
+		for _, d := range compiled {
+			if d.Code == LintUnusedImport {
+				diags = append(diags, d)
+			}
+		}
+	}
+	diags = w.applyLintConfig(diags)
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return diags, nil
+}
+
+// lintFiles runs the rules checked on the syntax tree over files
+func (w *Workspace) lintFiles(files []string, overlay map[string]string) ([]Diagnostic, error) {
+	var diags []Diagnostic
+	for _, name := range files {
+		content, ok := overlay[name]
+		if !ok {
+			data, err := os.ReadFile(w.protoPath(name))
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", name, err)
+			}
+			content = string(data)
+		}
+		diags = append(diags, w.lintFile(name, content)...)
+	}
+	return diags, nil
+}
+
+// lintFile runs the rules checked on the syntax tree over one file
+func (w *Workspace) lintFile(name, content string) []Diagnostic {
+	file, err := parseProto(name, content)
+	if err != nil {
+		var posErr reporter.ErrorWithPos
+		if errors.As(err, &posErr) {
+			return []Diagnostic{newDiagnostic(posErr, SeverityError)}
+		}
+		return []Diagnostic{{File: name, Severity: SeverityError, Message: err.Error(), Code: CodeSyntax}}
+	}
+
+	l := &protoLinter{ws: w, name: name, file: file}
+	l.lint()
+	return l.diags
+}
+
+// protoLinter collects the findings of one parsed file
+type protoLinter struct {
+	ws    *Workspace
+	name  string
+	file  *ast.FileNode
+	diags []Diagnostic
+}
+
+// report records a finding of rule at node, unless the rule is off
+func (l *protoLinter) report(rule string, node ast.Node, format string, args ...interface{}) {
+	severity := l.ws.lintSeverity(rule)
+	if severity == LintOff {
+		return
+	}
+	info := l.file.NodeInfo(node)
+	start, end := info.Start(), info.End()
+	l.diags = append(l.diags, Diagnostic{
+		File:      l.name,
+		Line:      start.Line,
+		Column:    start.Col,
+		EndLine:   end.Line,
+		EndColumn: end.Col,
+		Severity:  severity,
+		Message:   fmt.Sprintf(format, args...),
+		Code:      rule,
+	})
+}
+
+// lint checks the file level rules and walks the declarations
+func (l *protoLinter) lint() {
+	var pkg *ast.PackageNode
+	goPackage := false
+	for _, decl := range l.file.Decls {
+		switch decl := decl.(type) {
+		case *ast.PackageNode:
+			pkg = decl
+		case *ast.OptionNode:
+			if optionName(decl) == "go_package" {
+				goPackage = true
+			}
+		case *ast.MessageNode:
+			l.message(decl)
+		case *ast.EnumNode:
+			l.enum(decl)
+		case *ast.ExtendNode:
+			for _, d := range decl.Decls {
+				if field, ok := d.(*ast.FieldNode); ok {
+					l.field(field.Name)
+				}
+			}
+		case *ast.ServiceNode:
+			l.service(decl)
+		}
+	}
+
+	var fileNode ast.Node = l.file
+	switch {
+	case pkg != nil:
+		fileNode = pkg
+	case l.file.Syntax != nil:
+		fileNode = l.file.Syntax
+	case l.file.Edition != nil:
+		fileNode = l.file.Edition
+	}
+	if !goPackage {
+		l.report(LintGoPackage, fileNode, "file does not set the go_package option")
+	}
+
+	if pkg != nil {
+		dir := path.Dir(l.name)
+		want := strings.ReplaceAll(string(pkg.Name.AsIdentifier()), ".", "/")
+		if dir != want {
+			if dir == "." {
+				dir = "the pb root"
+			}
+			l.report(LintPackageDirectory, pkg.Name, "package %s is in %s, expected directory %s", pkg.Name.AsIdentifier(), dir, want)
+		}
+	}
+}
+
+// message checks a message and the declarations nested in it
+func (l *protoLinter) message(msg *ast.MessageNode) {
+	if !pascalCasePattern.MatchString(msg.Name.Val) {
+		l.report(LintMessagePascalCase, msg.Name, "message name %s is not PascalCase", msg.Name.Val)
+	}
+	l.messageBody(msg.Decls)
+}
+
+// messageBody checks the declarations of a message or group body
+func (l *protoLinter) messageBody(decls []ast.MessageElement) {
+	for _, decl := range decls {
+		switch decl := decl.(type) {
+		case *ast.FieldNode:
+			l.field(decl.Name)
+		case *ast.MapFieldNode:
+			l.field(decl.Name)
+		case *ast.GroupNode:
+			l.messageBody(decl.Decls)
+		case *ast.OneofNode:
+			for _, d := range decl.Decls {
+				switch d := d.(type) {
+				case *ast.FieldNode:
+					l.field(d.Name)
+				case *ast.GroupNode:
+					l.messageBody(d.Decls)
+				}
+			}
+		case *ast.MessageNode:
+			l.message(decl)
+		case *ast.EnumNode:
+			l.enum(decl)
+		case *ast.ExtendNode:
+			for _, d := range decl.Decls {
+				if field, ok := d.(*ast.FieldNode); ok {
+					l.field(field.Name)
+				}
+			}
+		}
+	}
+}
+
+// field checks the name of a field
+func (l *protoLinter) field(name *ast.IdentNode) {
+	if !snakeCasePattern.MatchString(name.Val) {
+		l.report(LintFieldSnakeCase, name, "field name %s is not lower_snake_case", name.Val)
+	}
+}
+
+// enum checks the name of the value numbered zero, wherever it is declared
+func (l *protoLinter) enum(enum *ast.EnumNode) {
+	for _, decl := range enum.Decls {
+		value, ok := decl.(*ast.EnumValueNode)
+		if !ok {
+			continue
+		}
+		if number, ok := value.Number.AsInt64(); !ok || number != 0 {
+			continue
+		}
+		if !strings.HasSuffix(value.Name.Val, "_UNSPECIFIED") {
+			l.report(LintEnumZeroUnspecified, value.Name, "zero value %s of enum %s does not end in _UNSPECIFIED", value.Name.Val, enum.Name.Val)
+		}
+		return
+	}
+}
+
+// service checks the RPCs of a service
+func (l *protoLinter) service(service *ast.ServiceNode) {
+	for _, decl := range service.Decls {
+		rpc, ok := decl.(*ast.RPCNode)
+		if !ok {
+			continue
+		}
+		if l.file.NodeInfo(rpc).LeadingComments().Len() == 0 {
+			l.report(LintRPCComment, rpc.Name, "RPC %s has no comment", rpc.Name.Val)
+		}
+		if input := messageTypeName(rpc.Input); input != emptyMessage && !strings.HasSuffix(input, "Request") {
+			l.report(LintRPCRequestSuffix, rpc.Input.MessageType, "request type %s of RPC %s does not end in Request", input, rpc.Name.Val)
+		}
+		if output := messageTypeName(rpc.Output); output != emptyMessage && !strings.HasSuffix(output, "Response") {
+			l.report(LintRPCResponseSuffix, rpc.Output.MessageType, "response type %s of RPC %s does not end in Response", output, rpc.Name.Val)
+		}
+	}
+}
+
+// messageTypeName returns the type of an RPC request or response as written
+// in the source, without a leading dot
+func messageTypeName(t *ast.RPCTypeNode) string {
+	return strings.TrimPrefix(string(t.MessageType.AsIdentifier()), ".")
+}
+
+// optionName returns the name of an option declaration as written, with
+// custom option names in parentheses
+func optionName(opt *ast.OptionNode) string {
+	parts := make([]string, len(opt.Name.Parts))
+	for i, part := range opt.Name.Parts {
+		parts[i] = part.Value()
+	}
+	return strings.Join(parts, ".")
+}
+
+// ListLintRules returns the lint rules with their severity in the current
+// workspace
+func (a *App) ListLintRules() ([]LintRule, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]LintRule, len(lintRules))
+	for i, rule := range lintRules {
+		rule.Severity = ws.lintSeverity(rule.ID)
+		rules[i] = rule
+	}
+	return rules, nil
+}
+
+// LintPB checks content as the proto at filename, without saving it, and
+// returns the lint findings for the editor
+func (a *App) LintPB(filename, content string) ([]Diagnostic, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ws.resolveProtoFile(filename); err != nil {
+		return nil, err
+	}
+
+	diags, err := ws.Lint(context.Background(), []string{cleanProtoPath(filename)}, map[string]string{cleanProtoPath(filename): content})
+	if err != nil {
+		return nil, err
+	}
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	return diags, nil
+}
+
+// LintWorkspace checks every proto of the workspace
+func (a *App) LintWorkspace() ([]Diagnostic, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	files, err := ws.ProtoFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	diags, err := ws.Lint(context.Background(), files, nil)
+	if err != nil {
+		return nil, err
+	}
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	return diags, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// lintCodes returns the file, line and code of each diagnostic
+func lintCodes(diags []Diagnostic) []string {
+	codes := make([]string, len(diags))
+	for i, d := range diags {
+		codes[i] = fmt.Sprintf("%s:%d %s %s", d.File, d.Line, d.Severity, d.Code)
+	}
+	return codes
+}
+
+// TestLintWorkspace checks every rule and that the workspace configuration
+// changes rule severities and disables rules
+func TestLintWorkspace(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{
+		"shop/v1/clean.proto": `syntax = "proto3";
+package shop.v1;
+option go_package = "example.com/shop/v1;shopv1";
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+}
+
+message GetOrderRequest {
+  string order_id = 1;
+}
+
+message GetOrderResponse {
+  Status status = 1;
+}
+
+service OrderService {
+  // GetOrder returns an order
+  rpc GetOrder (GetOrderRequest) returns (GetOrderResponse);
+}
+`,
+		"shop/messy.proto": `syntax = "proto3";
+package shop.v1;
+import "shop/v1/clean.proto";
+
+enum Kind {
+  NONE = 0;
+}
+
+message order_item {
+  string ItemID = 1;
+}
+
+service Messy {
+  rpc Get (order_item) returns (order_item);
+}
+`,
+	})
+
+	diags, err := app.LintWorkspace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"shop/messy.proto:2 warning GO_PACKAGE",
+		"shop/messy.proto:2 warning PACKAGE_DIRECTORY_MATCH",
+		"shop/messy.proto:3 warning UNUSED_IMPORT",
+		"shop/messy.proto:6 warning ENUM_ZERO_UNSPECIFIED",
+		"shop/messy.proto:9 warning MESSAGE_PASCAL_CASE",
+		"shop/messy.proto:10 warning FIELD_SNAKE_CASE",
+		"shop/messy.proto:14 warning RPC_COMMENT",
+		"shop/messy.proto:14 warning RPC_REQUEST_SUFFIX",
+		"shop/messy.proto:14 warning RPC_RESPONSE_SUFFIX",
+	}
+	if got := lintCodes(diags); !reflect.DeepEqual(got, want) {
+		t.Errorf("LintWorkspace = %v, want %v", got, want)
+	}
+
+	lint := map[string]string{LintRPCComment: LintOff, LintUnusedImport: LintOff, LintGoPackage: SeverityError}
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"lint": lint}); err != nil {
+		t.Fatal(err)
+	}
+	diags, err = app.LintPB("shop/messy.proto", "syntax = \"proto3\";\npackage shop;\nimport \"shop/v1/clean.proto\";\nservice Messy {\n  rpc Get (shop.v1.GetOrderRequest) returns (shop.v1.GetOrderResponse);\n}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"shop/messy.proto:2 error GO_PACKAGE"}
+	if got := lintCodes(diags); !reflect.DeepEqual(got, want) {
+		t.Errorf("LintPB = %v, want %v", got, want)
+	}
+
+	// Lint errors stop the generation
+	result := app.GenerateGRPC("shop/messy.proto", "syntax = \"proto3\";\npackage shop;\nmessage A {}\n", "", ScopeFile)
+	if result.Status != StatusFailed || !hasErrors(result.Diagnostics) {
+		t.Errorf("generation with lint errors = %s, %v", result.Status, result.Diagnostics)
+	}
+
+	if diags, err := app.LintPB("shop/messy.proto", "syntax = \"proto3\";\nmessage {"); err != nil || len(diags) != 1 || diags[0].Code != CodeSyntax {
+		t.Errorf("LintPB on a syntax error = %v, %v", diags, err)
+	}
+}
+
+// TestLintEnumZero checks that the enum rule looks at the value numbered
+// zero, not at the first declared one
+func TestLintEnumZero(t *testing.T) {
+	app := NewApp()
+	if _, err := app.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	diags, err := app.LintPB("shop/v1/enums.proto", `syntax = "proto2";
+package shop.v1;
+option go_package = "example.com/shop/v1;shopv1";
+
+enum State {
+  STATE_ACTIVE = 1;
+  STATE_UNSPECIFIED = 0;
+}
+
+enum Color {
+  COLOR_RED = 1;
+  NONE = 0;
+}
+
+enum Size {
+  SIZE_SMALL = 1;
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := lintCodes(diags), []string{"shop/v1/enums.proto:12 warning ENUM_ZERO_UNSPECIFIED"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LintPB = %v, want %v", got, want)
+	}
+}
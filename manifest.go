@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// outputManifestFile holds the output manifest, relative to the workspace root
+var outputManifestFile = filepath.Join(".pb-tool", "output-manifest.json")
+
+// outputManifest records which outputs every proto produced, per plugin, so
+// outputs left without a source can be found and removed. Unlike the
+// generation cache it is never cleared by the user.
+type outputManifest struct {
+	path string
+	// Protos maps a proto to its outputs
+	Protos map[string][]manifestOutput `json:"protos"`
+}
+
+// manifestOutput is a file a plugin wrote for a proto. Name is relative to
+// Out, the plugin output directory.
+type manifestOutput struct {
+	Plugin string `json:"plugin"`
+	Out    string `json:"out"`
+	Name   string `json:"name"`
+}
+
+// RemovedFile is an output deleted because its proto no longer produces it
+type RemovedFile struct {
+	Name   string `json:"name"`
+	Out    string `json:"out"`
+	Plugin string `json:"plugin"`
+	Proto  string `json:"proto"`
+}
+
+// loadOutputManifest reads the manifest of the workspace at root. Without a
+// manifest, as in workspaces generated before it existed, it is seeded from
+// the generation cache.
+func loadOutputManifest(root string, cache *generateCache) *outputManifest {
+	m := &outputManifest{
+		path:   filepath.Join(root, outputManifestFile),
+		Protos: make(map[string][]manifestOutput),
+	}
+	content, err := os.ReadFile(m.path)
+	if err == nil {
+		if err := json.Unmarshal(content, m); err != nil || m.Protos == nil {
+			m.Protos = make(map[string][]manifestOutput)
+		}
+		return m
+	}
+
+	for id, entry := range cache.Entries {
+		parts := strings.SplitN(id, "\x00", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, out := range entry.Outputs {
+			m.Protos[parts[2]] = append(m.Protos[parts[2]], manifestOutput{Plugin: parts[0], Out: parts[1], Name: out.Name})
+		}
+	}
+	return m
+}
+
+// save writes the manifest, replacing the previous file atomically
+func (m *outputManifest) save() error {
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+// record replaces the outputs gen produced for file and returns those it
+// produced before but no longer does
+func (m *outputManifest) record(file string, gen *generator, names []string) []manifestOutput {
+	produced := make(map[string]bool, len(names))
+	for _, name := range names {
+		produced[name] = true
+	}
+
+	var kept, stale []manifestOutput
+	for _, out := range m.Protos[file] {
+		switch {
+		case out.Plugin != gen.Name || out.Out != gen.OutDir:
+			kept = append(kept, out)
+		case !produced[out.Name]:
+			stale = append(stale, out)
+		}
+	}
+	for _, name := range names {
+		kept = append(kept, manifestOutput{Plugin: gen.Name, Out: gen.OutDir, Name: name})
+	}
+	m.Protos[file] = kept
+	return stale
+}
+
+// orphans removes the protos missing from protos and returns their
+// outputs, by proto
+func (m *outputManifest) orphans(protos []string) map[string][]manifestOutput {
+	exists := make(map[string]bool, len(protos))
+	for _, proto := range protos {
+		exists[proto] = true
+	}
+
+	orphans := make(map[string][]manifestOutput)
+	for proto, outputs := range m.Protos {
+		if !exists[proto] {
+			orphans[proto] = outputs
+			delete(m.Protos, proto)
+		}
+	}
+	return orphans
+}
+
+// claimed reports whether some proto still produces out
+func (m *outputManifest) claimed(out manifestOutput) bool {
+	for _, outputs := range m.Protos {
+		for _, o := range outputs {
+			if o.Out == out.Out && o.Name == out.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeOutputs deletes the outputs of proto that no proto produces any
+// more from the directory dir maps their output directory to, and records
+// them in the result
+func (r *GenerateResult) removeOutputs(m *outputManifest, proto string, outputs []manifestOutput, dir func(string) string) {
+	for _, out := range outputs {
+		if m.claimed(out) {
+			continue
+		}
+		path := filepath.Join(dir(out.Out), filepath.FromSlash(out.Name))
+		if err := os.Remove(path); err != nil {
+			if !os.IsNotExist(err) {
+				r.Diagnostics = append(r.Diagnostics, Diagnostic{
+					File:     proto,
+					Severity: SeverityWarning,
+					Message:  "removing orphaned output " + out.Name + ": " + err.Error(),
+					Code:     CodeOutput,
+				})
+			}
+			continue
+		}
+		removeEmptyDirs(filepath.Dir(path), dir(out.Out))
+		r.Removed = append(r.Removed, RemovedFile{Name: out.Name, Out: out.Out, Plugin: out.Plugin, Proto: proto})
+	}
+}
+
+// removeEmptyDirs removes dir and its empty parents up to, but excluding, stop
+func removeEmptyDirs(dir, stop string) {
+	for dir != stop && isWithin(dir, stop) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// removeOrphans deletes the outputs of protos that no longer exist in the
+// workspace
+func (r *GenerateResult) removeOrphans(m *outputManifest, protos []string, dir func(string) string) {
+	orphans := m.orphans(protos)
+	names := make([]string, 0, len(orphans))
+	for proto := range orphans {
+		names = append(names, proto)
+	}
+	sort.Strings(names)
+	for _, proto := range names {
+		r.removeOutputs(m, proto, orphans[proto], dir)
+	}
+}
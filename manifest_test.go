@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerateGRPC_Orphans checks that the outputs of a renamed proto are
+// removed and reported by the next run
+func TestGenerateGRPC_Orphans(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "syntax = \"proto3\";\npackage demo;\noption go_package = \"example.com/demo\";\nmessage Ping { string id = 1; }\n"
+	if result := app.GenerateGRPC("old.proto", content, "", ScopeFile); result.Status == StatusFailed {
+		t.Fatalf("generation failed: %s", result.Summary)
+	}
+	if _, err := os.Stat(filepath.Join(ws.OutputPath(), "old.pb.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rename the proto, as a user would outside the editor
+	if err := os.Remove(filepath.Join(ws.PBPath(), "old.proto")); err != nil {
+		t.Fatal(err)
+	}
+	result := app.GenerateGRPC("new.proto", content, "", ScopeFile)
+	if result.Status == StatusFailed {
+		t.Fatalf("generation failed: %s", result.Summary)
+	}
+
+	if len(result.Removed) != 1 || result.Removed[0].Name != "old.pb.go" || result.Removed[0].Proto != "old.proto" {
+		t.Fatalf("removed = %+v, want old.pb.go of old.proto", result.Removed)
+	}
+	if _, err := os.Stat(filepath.Join(ws.OutputPath(), "old.pb.go")); !os.IsNotExist(err) {
+		t.Errorf("old.pb.go still exists: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ws.OutputPath(), "new.pb.go")); err != nil {
+		t.Error(err)
+	}
+
+	// The removal is recorded, so later runs do not report it again
+	if result := app.GenerateGRPC("new.proto", content, "", ScopeFile); len(result.Removed) != 0 {
+		t.Errorf("removed = %+v on the next run, want none", result.Removed)
+	}
+}
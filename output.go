@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OutputSnapshot is the previous content of an output directory, kept by
+// the last generation that replaced it
+type OutputSnapshot struct {
+	OutDir    string    `json:"outDir"`
+	Path      string    `json:"path"`
+	Files     int       `json:"files"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// snapshotPath returns the rollback snapshot location of an output directory,
+// a hidden sibling so that swapping it in is a rename
+func snapshotPath(outDir string) string {
+	return filepath.Join(filepath.Dir(outDir), "."+filepath.Base(outDir)+".previous")
+}
+
+// stagingPath returns the staging location of an output directory for a job
+func stagingPath(outDir, id string) string {
+	return filepath.Join(filepath.Dir(outDir), "."+filepath.Base(outDir)+".staging-"+id)
+}
+
+// isWithin reports whether path is dir or below it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// outputStage redirects the writes of a generation into staging copies of
+// its output directories, so they replace the real ones only once every
+// plugin has succeeded
+type outputStage struct {
+	// staged maps each staged output directory to its staging copy
+	staged map[string]string
+}
+
+// stageOutputs creates a staging copy of every output directory. Nested
+// output directories share the copy of the outermost one. A directory that
+// is the workspace root or holds the protos cannot be swapped and is
+// written in place.
+func stageOutputs(ws *Workspace, outDirs []string, id string) (*outputStage, error) {
+	dirs := append([]string{}, outDirs...)
+	sort.Strings(dirs)
+
+	stage := &outputStage{staged: make(map[string]string)}
+	for _, dir := range dirs {
+		if _, ok := stage.outer(dir); ok {
+			continue
+		}
+		if isWithin(ws.Root, dir) || ws.holdsSourceRoot(dir) {
+			continue
+		}
+
+		staging := stagingPath(dir, id)
+		if err := copyTree(dir, staging); err != nil {
+			stage.discard()
+			return nil, fmt.Errorf("staging %s: %w", dir, err)
+		}
+		stage.staged[dir] = staging
+	}
+	return stage, nil
+}
+
+// outer returns the staged directory containing dir
+func (s *outputStage) outer(dir string) (string, bool) {
+	for staged := range s.staged {
+		if isWithin(dir, staged) {
+			return staged, true
+		}
+	}
+	return "", false
+}
+
+// dir returns where the outputs for outDir are written during the run
+func (s *outputStage) dir(outDir string) string {
+	staged, ok := s.outer(outDir)
+	if !ok {
+		return outDir
+	}
+	rel, _ := filepath.Rel(staged, outDir)
+	return filepath.Join(s.staged[staged], rel)
+}
+
+// commit swaps every staging copy into place, moving the replaced output
+// directory to its rollback snapshot
+func (s *outputStage) commit() error {
+	for dir, staging := range s.staged {
+		if err := swapIn(dir, staging); err != nil {
+			return err
+		}
+		delete(s.staged, dir)
+	}
+	return nil
+}
+
+// discard removes the staging copies, leaving the outputs untouched
+func (s *outputStage) discard() {
+	for dir, staging := range s.staged {
+		os.RemoveAll(staging)
+		delete(s.staged, dir)
+	}
+}
+
+// swapIn replaces dir with replacement, keeping the old dir as its
+// snapshot. Each step is a rename, so dir is never partially written; if
+// the second rename fails the old dir is moved back.
+func swapIn(dir, replacement string) error {
+	snapshot := snapshotPath(dir)
+	if err := os.RemoveAll(snapshot); err != nil {
+		return err
+	}
+
+	_, err := os.Stat(dir)
+	hadDir := err == nil
+	if hadDir {
+		if err := os.Rename(dir, snapshot); err != nil {
+			return fmt.Errorf("moving %s aside: %w", dir, err)
+		}
+		now := time.Now()
+		os.Chtimes(snapshot, now, now)
+	} else if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+
+	if err := os.Rename(replacement, dir); err != nil {
+		if hadDir {
+			os.Rename(snapshot, dir)
+		}
+		return fmt.Errorf("swapping in %s: %w", dir, err)
+	}
+	return nil
+}
+
+// copyTree copies the files below src to dst. A missing src yields an
+// empty dst.
+func copyTree(src, dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies a regular file, keeping its permissions
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// outputDirs returns the distinct output directories of the pipeline
+func (w *Workspace) outputDirs() []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, p := range w.Plugins() {
+		dir := w.OutputPath()
+		if p.Out != "" {
+			dir = w.path(p.Out)
+		}
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// GetOutputSnapshots lists the rollback snapshots of the output directories
+// of the active workspace
+func (a *App) GetOutputSnapshots() ([]OutputSnapshot, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []OutputSnapshot{}
+	for _, dir := range ws.outputDirs() {
+		path := snapshotPath(dir)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		files := 0
+		filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files++
+			}
+			return nil
+		})
+		snapshots = append(snapshots, OutputSnapshot{
+			OutDir:    dir,
+			Path:      path,
+			Files:     files,
+			CreatedAt: info.ModTime(),
+		})
+	}
+	return snapshots, nil
+}
+
+// RestoreOutputSnapshot swaps the rollback snapshot of outDir back into
+// place. The replaced output becomes the new snapshot, so a restore can be
+// undone the same way.
+func (a *App) RestoreOutputSnapshot(outDir string) error {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return err
+	}
+
+	known := false
+	for _, dir := range ws.outputDirs() {
+		known = known || dir == outDir
+	}
+	if !known {
+		return fmt.Errorf("%s is not an output directory of the workspace", outDir)
+	}
+
+	// Hold the scheduler lock so no generation job starts on the workspace
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+	if a.jobRunners[ws.Root] {
+		return fmt.Errorf("a generation job is running in %s", ws.Name)
+	}
+
+	snapshot := snapshotPath(outDir)
+	if _, err := os.Stat(snapshot); err != nil {
+		return fmt.Errorf("no snapshot of %s to restore", outDir)
+	}
+
+	// Move the snapshot aside first, since swapIn replaces the snapshot slot
+	restoring := snapshot + ".restore"
+	if err := os.RemoveAll(restoring); err != nil {
+		return err
+	}
+	if err := os.Rename(snapshot, restoring); err != nil {
+		return err
+	}
+	if err := swapIn(outDir, restoring); err != nil {
+		os.Rename(restoring, snapshot)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestGenerateGRPC_Snapshot checks that successful runs swap their output in
+// and keep the previous one, that failed runs leave the output untouched and
+// that a snapshot can be restored
+func TestGenerateGRPC_Snapshot(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plugins := []PluginConfig{{Name: "go", Out: "gen", Opt: []string{"paths=source_relative"}}}
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"plugins": plugins}); err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(ws.Root, "gen")
+	output := filepath.Join(outDir, "demo.pb.go")
+
+	proto := func(message string) string {
+		return "syntax = \"proto3\";\npackage demo;\noption go_package = \"example.com/demo\";\nmessage " + message + " { string id = 1; }\n"
+	}
+	for _, message := range []string{"First", "Second"} {
+		if result := app.GenerateGRPC("demo.proto", proto(message), "", ScopeFile); result.Status != StatusSuccess {
+			t.Fatalf("generating %s: %s", message, result.Summary)
+		}
+	}
+
+	generated, err := os.ReadFile(output)
+	if err != nil || !strings.Contains(string(generated), "type Second struct") {
+		t.Fatalf("output does not hold the second generation: %v", err)
+	}
+	snapshots, err := app.GetOutputSnapshots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].OutDir != outDir || snapshots[0].Files != 1 {
+		t.Fatalf("snapshots = %+v, want one of %s", snapshots, outDir)
+	}
+
+	// A run whose only plugin fails keeps the current output
+	plugins = []PluginConfig{{Name: "missing", Path: "protoc-gen-does-not-exist", Out: "gen"}}
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"plugins": plugins}); err != nil {
+		t.Fatal(err)
+	}
+	result := app.GenerateGRPC("demo.proto", proto("Third"), "", ScopeFile)
+	if result.Status != StatusFailed || !result.Discarded {
+		t.Fatalf("status = %s, discarded = %v, want a discarded failure", result.Status, result.Discarded)
+	}
+	if generated, err = os.ReadFile(output); err != nil || !strings.Contains(string(generated), "type Second struct") {
+		t.Fatalf("failed run changed the output: %v", err)
+	}
+	entries, _ := os.ReadDir(ws.Root)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".staging-") {
+			t.Errorf("staging directory %s left behind", entry.Name())
+		}
+	}
+
+	// Restoring brings back the first generation, restoring again undoes it
+	if err := app.RestoreOutputSnapshot(outDir); err != nil {
+		t.Fatal(err)
+	}
+	if generated, err = os.ReadFile(output); err != nil || !strings.Contains(string(generated), "type First struct") {
+		t.Fatalf("restore did not bring back the first generation: %v", err)
+	}
+	if err := app.RestoreOutputSnapshot(outDir); err != nil {
+		t.Fatal(err)
+	}
+	if generated, err = os.ReadFile(output); err != nil || !strings.Contains(string(generated), "type Second struct") {
+		t.Fatalf("second restore did not undo the first: %v", err)
+	}
+}
+
+// TestGeneratedFiles checks that nested outputs are listed and read by their
+// relative path, and that reads cannot leave the output directory
+func TestGeneratedFiles(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.OutputPath(), map[string]string{
+		"top.pb.go":               "package top\n",
+		"example.com/api/v1/a.go": "package v1\n",
+		".hidden/ignored.go":      "package hidden\n",
+	})
+	writeFiles(t, ws.Root, map[string]string{"secret.txt": "secret"})
+
+	var names []string
+	for _, file := range app.GetGeneratedFiles() {
+		names = append(names, file["name"].(string))
+	}
+	sort.Strings(names)
+	if want := []string{"example.com/api/v1/a.go", "top.pb.go"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("GetGeneratedFiles = %v, want %v", names, want)
+	}
+	if got := app.ReadGeneratedFile("example.com/api/v1/a.go"); got != "package v1\n" {
+		t.Errorf("ReadGeneratedFile = %q", got)
+	}
+	for _, name := range []string{"../../secret.txt", "/etc/passwd"} {
+		if got := app.ReadGeneratedFile(name); !strings.HasPrefix(got, "Error") {
+			t.Errorf("ReadGeneratedFile(%q) = %q, want an error", name, got)
+		}
+	}
+}
+
+// TestGenerateGRPC_RequiredPluginFails checks that a run in which a
+// required plugin fails keeps the previous output, even when another
+// plugin succeeded
+func TestGenerateGRPC_RequiredPluginFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the failing plugin is a shell script")
+	}
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing := filepath.Join(ws.Root, "protoc-gen-fail")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho broken >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	proto := func(message string) string {
+		return "syntax = \"proto3\";\npackage demo;\noption go_package = \"example.com/demo\";\nmessage " + message + " { string id = 1; }\n"
+	}
+	plugins := []PluginConfig{{Name: "go", Out: "gen", Opt: []string{"paths=source_relative"}}}
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"plugins": plugins}); err != nil {
+		t.Fatal(err)
+	}
+	if result := app.GenerateGRPC("demo.proto", proto("First"), "", ScopeFile); result.Status != StatusSuccess {
+		t.Fatalf("first generation: %s", result.Summary)
+	}
+
+	plugins = append(plugins, PluginConfig{Name: "fail", Path: failing, Out: "gen"})
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"plugins": plugins}); err != nil {
+		t.Fatal(err)
+	}
+	result := app.GenerateGRPC("demo.proto", proto("Second"), "", ScopeFile)
+	if len(result.Plugins) != 2 || result.Plugins[0].Status != StatusSuccess || result.Plugins[1].Status != StatusFailed {
+		t.Fatalf("plugins = %+v, want go to succeed and fail to fail", result.Plugins)
+	}
+	if result.Status != StatusPartial || !result.Discarded {
+		t.Fatalf("status = %s, discarded = %v, want a discarded partial run", result.Status, result.Discarded)
+	}
+	generated, err := os.ReadFile(filepath.Join(ws.Root, "gen", "demo.pb.go"))
+	if err != nil || !strings.Contains(string(generated), "type First struct") || strings.Contains(string(generated), "type Second struct") {
+		t.Fatalf("failed run changed the output: %v", err)
+	}
+}
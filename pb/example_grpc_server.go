@@ -5,10 +5,17 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 
@@ -223,26 +230,185 @@ func getMethodNameFromFullMethod(fullMethod string) string {
 	return fullMethod
 }
 
+// BootstrapOption 服务器启动选项，与 CustomServerOption 的用法相同
+type BootstrapOption func(*BootstrapOptions)
+
+// BootstrapOptions 服务器启动配置
+type BootstrapOptions struct {
+	// Address 监听地址
+	Address string
+	// Reflection 是否注册 gRPC 反射服务（v1 和 v1alpha），供 pb-tool、grpcurl 等工具读取接口定义
+	Reflection bool
+	// Health 是否注册标准健康检查服务 grpc.health.v1.Health
+	Health bool
+	// ShutdownSignals 收到这些信号时优雅关闭，为空时不监听信号
+	ShutdownSignals []os.Signal
+	// ShutdownTimeout 优雅关闭的最长等待时间，超时后强制停止；为 0 时一直等待
+	ShutdownTimeout time.Duration
+	// GRPCOptions 创建 grpc.Server 时的额外选项，例如拦截器
+	GRPCOptions []grpc.ServerOption
+}
+
+// WithAddress 设置监听地址，默认 :50051
+func WithAddress(address string) BootstrapOption {
+	return func(opts *BootstrapOptions) {
+		opts.Address = address
+	}
+}
+
+// WithReflection 是否注册 gRPC 反射服务，默认注册
+func WithReflection(enabled bool) BootstrapOption {
+	return func(opts *BootstrapOptions) {
+		opts.Reflection = enabled
+	}
+}
+
+// WithHealth 是否注册健康检查服务，默认注册
+func WithHealth(enabled bool) BootstrapOption {
+	return func(opts *BootstrapOptions) {
+		opts.Health = enabled
+	}
+}
+
+// WithShutdownSignals 设置触发优雅关闭的信号，默认 SIGINT 和 SIGTERM；不传信号则不监听
+func WithShutdownSignals(signals ...os.Signal) BootstrapOption {
+	return func(opts *BootstrapOptions) {
+		opts.ShutdownSignals = signals
+	}
+}
+
+// WithShutdownTimeout 设置优雅关闭的最长等待时间，默认 10 秒
+func WithShutdownTimeout(timeout time.Duration) BootstrapOption {
+	return func(opts *BootstrapOptions) {
+		opts.ShutdownTimeout = timeout
+	}
+}
+
+// WithGRPCOptions 追加创建 grpc.Server 时的选项
+func WithGRPCOptions(grpcOpts ...grpc.ServerOption) BootstrapOption {
+	return func(opts *BootstrapOptions) {
+		opts.GRPCOptions = append(opts.GRPCOptions, grpcOpts...)
+	}
+}
+
+// GRPCServer 可复用的 gRPC 服务器：注册反射和健康检查服务，收到信号时优雅关闭。
+// 业务服务通过内嵌的 *grpc.Server 注册。
+type GRPCServer struct {
+	*grpc.Server
+	opts   BootstrapOptions
+	health *health.Server
+}
+
+// NewGRPCServer 按选项创建服务器
+func NewGRPCServer(opts ...BootstrapOption) *GRPCServer {
+	// 默认选项
+	bootstrapOpts := BootstrapOptions{
+		Address:         ":50051",
+		Reflection:      true,
+		Health:          true,
+		ShutdownSignals: []os.Signal{os.Interrupt, syscall.SIGTERM},
+		ShutdownTimeout: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&bootstrapOpts)
+	}
+
+	s := &GRPCServer{
+		Server: grpc.NewServer(bootstrapOpts.GRPCOptions...),
+		opts:   bootstrapOpts,
+	}
+	if bootstrapOpts.Reflection {
+		reflection.Register(s.Server)
+	}
+	if bootstrapOpts.Health {
+		s.health = health.NewServer()
+		healthpb.RegisterHealthServer(s.Server, s.health)
+	}
+	return s
+}
+
+// ListenAndServe 监听配置的地址并提供服务，直到收到关闭信号或调用 Shutdown
+func (s *GRPCServer) ListenAndServe() error {
+	lis, err := net.Listen("tcp", s.opts.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	fmt.Printf("gRPC服务器启动在 %s\n", lis.Addr())
+	return s.Serve(lis)
+}
+
+// Serve 在 lis 上提供服务。已注册的服务在健康检查中标记为 SERVING；
+// 收到关闭信号时优雅关闭，关闭完成后返回 nil。
+func (s *GRPCServer) Serve(lis net.Listener) error {
+	if s.health != nil {
+		// 空服务名表示整个服务器的状态
+		s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+		for name := range s.Server.GetServiceInfo() {
+			s.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	if len(s.opts.ShutdownSignals) > 0 {
+		signal.Notify(signals, s.opts.ShutdownSignals...)
+		defer signal.Stop(signals)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Server.Serve(lis)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case sig := <-signals:
+		fmt.Printf("收到信号 %s，开始优雅关闭\n", sig)
+		s.Shutdown()
+		return <-served
+	}
+}
+
+// Shutdown 优雅关闭：健康检查先标记为 NOT_SERVING，不再接受新请求并等待进行中的请求完成，
+// 超过 ShutdownTimeout 后强制停止
+func (s *GRPCServer) Shutdown() {
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Server.GracefulStop()
+		close(stopped)
+	}()
+	if s.opts.ShutdownTimeout <= 0 {
+		<-stopped
+		return
+	}
+	select {
+	case <-stopped:
+	case <-time.After(s.opts.ShutdownTimeout):
+		fmt.Println("优雅关闭超时，强制停止")
+		s.Server.Stop()
+		<-stopped
+	}
+}
+
 func main() {
-	// 创建gRPC服务器，添加publish拦截器
-	s := grpc.NewServer(
-		grpc.UnaryInterceptor(PublishInterceptor()),
+	// 创建gRPC服务器，添加publish拦截器；反射、健康检查和信号关闭使用默认配置
+	srv := NewGRPCServer(
+		WithAddress(":50051"),
+		WithGRPCOptions(grpc.UnaryInterceptor(PublishInterceptor())),
 	)
 
 	// 创建服务实例
 	exampleServer := &ExampleServer{}
 
 	// 注册服务，只注册publish=true的方法
-	RegisterExampleServiceWithOptions(s, exampleServer, WithPublishOnly(true))
-
-	// 启动服务器
-	lis, err := net.Listen("tcp", ":50051")
-	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
-	}
+	RegisterExampleServiceWithOptions(srv.Server, exampleServer, WithPublishOnly(true))
 
-	fmt.Println("gRPC服务器启动在 :50051")
-	if err := s.Serve(lis); err != nil {
+	// 启动服务器，收到 SIGINT 或 SIGTERM 后优雅关闭
+	if err := srv.ListenAndServe(); err != nil {
 		log.Fatalf("failed to serve: %v", err)
 	}
 }
@@ -1,7 +1,19 @@
 package pb
 
 import (
+	"context"
+	"net"
+	"os"
+	"runtime"
 	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+
+	generated_pb "pb-tool/grpc_output/pb"
 )
 
 // TestGetPublishOptionFromMethodName 测试GetPublishOptionFromMethodName方法
@@ -54,3 +66,122 @@ func TestGetPublishOptionFromMethodName_EmptyMethod(t *testing.T) {
 		t.Errorf("空方法名: 预期 publish=true, 实际 publish=%v", result)
 	}
 }
+
+// startTestServer 在随机端口启动服务器，返回客户端连接和 Serve 的返回值
+func startTestServer(t *testing.T, srv *GRPCServer) (*grpc.ClientConn, chan error) {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, served
+}
+
+// waitServed 等待 Serve 返回
+func waitServed(t *testing.T, served chan error) {
+	t.Helper()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve 返回错误: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("服务器未关闭")
+	}
+}
+
+// TestGRPCServer 测试服务器注册反射和健康检查服务，并在 Shutdown 后优雅关闭
+func TestGRPCServer(t *testing.T) {
+	srv := NewGRPCServer(WithShutdownSignals())
+	RegisterExampleServiceWithOptions(srv.Server, &ExampleServer{}, WithPublishOnly(true))
+	conn, served := startTestServer(t, srv)
+	ctx := context.Background()
+
+	// 健康检查：整个服务器和已注册的服务都是 SERVING
+	healthClient := healthpb.NewHealthClient(conn)
+	for _, service := range []string{"", "example.ExampleService"} {
+		resp, err := healthClient.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("健康检查 %q 失败: %v", service, err)
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("健康检查 %q: 预期 SERVING, 实际 %v", service, resp.Status)
+		}
+	}
+
+	// 反射：列出的服务包括业务服务
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{ListServices: "*"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := map[string]bool{}
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services[service.Name] = true
+	}
+	for _, name := range []string{"example.ExampleService", "grpc.health.v1.Health", "grpc.reflection.v1.ServerReflection"} {
+		if !services[name] {
+			t.Errorf("反射未列出服务 %s: %v", name, services)
+		}
+	}
+	stream.CloseSend()
+
+	// 业务服务正常调用
+	example, err := generated_pb.NewExampleServiceClient(conn).GetExample(ctx, &generated_pb.GetExampleRequest{Id: "42"})
+	if err != nil || example.Id != "42" {
+		t.Errorf("GetExample: %v, %v", example, err)
+	}
+
+	srv.Shutdown()
+	waitServed(t, served)
+}
+
+// TestGRPCServer_WithoutReflectionAndHealth 测试关闭反射和健康检查
+func TestGRPCServer_WithoutReflectionAndHealth(t *testing.T) {
+	srv := NewGRPCServer(WithReflection(false), WithHealth(false), WithShutdownSignals())
+	info := srv.GetServiceInfo()
+	if len(info) != 0 {
+		t.Errorf("预期未注册任何服务, 实际 %v", info)
+	}
+}
+
+// TestGRPCServer_ShutdownSignal 测试收到信号后优雅关闭
+func TestGRPCServer_ShutdownSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不支持向自身进程发送信号")
+	}
+	srv := NewGRPCServer(WithShutdownSignals(os.Interrupt), WithShutdownTimeout(time.Second))
+	conn, served := startTestServer(t, srv)
+
+	// 等待服务器开始服务，确保信号已被监听
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	waitServed(t, served)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// PluginConfig declares one step of the workspace generation pipeline,
+// modeled on a buf.gen.yaml plugin entry
+type PluginConfig struct {
+	// Name is the plugin name without the protoc-gen- prefix, such as go or go-grpc
+	Name string `json:"name"`
+	// Path is the plugin executable or "builtin" for an in-process generator.
+	// When empty a built-in generator of that name is used if there is one,
+	// otherwise protoc-gen-<name> is looked up in GOBIN, GOPATH/bin and PATH.
+	Path string `json:"path,omitempty"`
+	// Out is the output directory, relative to the workspace root.
+	// It defaults to the workspace output directory.
+	Out string `json:"out,omitempty"`
+	// Opt lists the plugin options, such as paths=source_relative or
+	// Mfoo.proto=example.com/foo mappings
+	Opt []string `json:"opt,omitempty"`
+	// Optional plugins are skipped rather than failed when not installed
+	Optional bool `json:"optional,omitempty"`
+}
+
+// builtinGenerator generates code in process from a plugin request
+type builtinGenerator func(*pluginpb.CodeGeneratorRequest) (*pluginpb.CodeGeneratorResponse, error)
+
+// builtinGenerators are the generators run without an executable, by plugin name
+var builtinGenerators = map[string]builtinGenerator{
+	"go": generateGoBuiltin,
+}
+
+// pluginInstallHints tells users how to install the plugins of the default pipeline
+var pluginInstallHints = map[string]string{
+	"go-grpc":      "go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest",
+	"grpc-gateway": "go install github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway@latest",
+}
+
+// generator is a pipeline step resolved against the workspace
+type generator struct {
+	Name      string
+	Path      string
+	OutDir    string
+	Parameter string
+	builtin   builtinGenerator
+	// stageDir is where outputs are written while the run is staged; empty
+	// when they are written to OutDir directly
+	stageDir string
+	// prepare edits the request before it is sent, for buf managed mode
+	prepare func(*pluginpb.CodeGeneratorRequest)
+}
+
+// writeDir returns the directory the generator writes its outputs to
+func (g *generator) writeDir() string {
+	if g.stageDir != "" {
+		return g.stageDir
+	}
+	return g.OutDir
+}
+
+// defaultPlugins returns the pipeline used when pb-tool.json declares none:
+// Go messages, gRPC stubs and, when the plugin is installed, gRPC Gateway
+// handlers configured by the workspace gateway.yaml
+func (w *Workspace) defaultPlugins() []PluginConfig {
+	gatewayOpt := []string{"paths=source_relative", "allow_delete_body=true"}
+	if _, err := os.Stat(filepath.Join(w.Root, "gateway.yaml")); err == nil {
+		gatewayOpt = append(gatewayOpt, "grpc_api_configuration=gateway.yaml")
+	}
+
+	return []PluginConfig{
+		{Name: "go", Path: builtinPluginPath, Opt: []string{"paths=source_relative"}},
+		{Name: "go-grpc", Opt: []string{"paths=source_relative"}, Optional: true},
+		{Name: "grpc-gateway", Opt: gatewayOpt, Optional: true},
+	}
+}
+
+// Plugins returns the generation pipeline of the workspace: the plugins of
+// pb-tool.json, else those of buf.gen.yaml, else the default pipeline
+func (w *Workspace) Plugins() []PluginConfig {
+	if len(w.Config.Plugins) > 0 {
+		return w.Config.Plugins
+	}
+	if w.Buf != nil && len(w.Buf.Plugins) > 0 {
+		return w.Buf.Plugins
+	}
+	return w.defaultPlugins()
+}
+
+// resolvePlugin finds the generator and output directory of a plugin entry
+func (w *Workspace) resolvePlugin(p PluginConfig) (*generator, error) {
+	if p.Name == "" {
+		return nil, fmt.Errorf("plugin entry without a name")
+	}
+
+	gen := &generator{
+		Name:      "protoc-gen-" + p.Name,
+		OutDir:    w.OutputPath(),
+		Parameter: strings.Join(p.Opt, ","),
+	}
+	if p.Out != "" {
+		gen.OutDir = w.path(p.Out)
+	}
+	if w.Buf != nil && w.Buf.Managed != nil {
+		gen.prepare = w.applyManaged
+	}
+
+	builtin, hasBuiltin := builtinGenerators[p.Name]
+	switch {
+	case p.Path == builtinPluginPath:
+		if !hasBuiltin {
+			return nil, fmt.Errorf("no built-in generator named %s", p.Name)
+		}
+		gen.Path, gen.builtin = builtinPluginPath, builtin
+	case p.Path == "" && hasBuiltin:
+		gen.Path, gen.builtin = builtinPluginPath, builtin
+	case p.Path == "":
+		path, err := findPlugin(gen.Name)
+		if err != nil {
+			msg := fmt.Sprintf("%s not found", gen.Name)
+			if hint, ok := pluginInstallHints[p.Name]; ok {
+				msg += "; install it with: " + hint
+			}
+			return nil, fmt.Errorf("%s", msg)
+		}
+		gen.Path = path
+	default:
+		// Bare names are searched like the default plugins, paths are
+		// relative to the workspace root
+		var path string
+		var err error
+		if strings.ContainsAny(p.Path, `/\`) {
+			path, err = exec.LookPath(w.path(filepath.FromSlash(p.Path)))
+		} else {
+			path, err = findPlugin(p.Path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s not found: %w", p.Path, err)
+		}
+		gen.Path = path
+	}
+	return gen, nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RenameResult describes a RenamePB call. Updated lists the workspace
+// protos whose imports were rewritten to the new path, Unchanged those
+// importing the old path in a form that could not be rewritten.
+type RenameResult struct {
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// DeletePB removes the proto at filename, a slash-separated path relative
+// to the workspace pb directory. It returns the workspace protos that still
+// import it; their imports are left for the user to fix. The generated
+// outputs of the proto are removed by the next generation run.
+func (a *App) DeletePB(filename string) ([]string, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	filePath, err := ws.resolveProtoFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(filePath); err != nil {
+		return nil, fmt.Errorf("deleting %s: %w", filename, err)
+	}
+
+	graph, err := ws.importers()
+	if err != nil {
+		return nil, err
+	}
+	importers := append([]string{}, graph[cleanProtoPath(filename)]...)
+	sort.Strings(importers)
+	return importers, nil
+}
+
+// RenamePB moves the proto at from to to, both relative to the workspace pb
+// directory, and rewrites the import statements of the workspace protos
+// that referenced the old path
+func (a *App) RenamePB(from, to string) (*RenameResult, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	return ws.renameProto(from, to)
+}
+
+// renameProto implements RenamePB
+func (w *Workspace) renameProto(from, to string) (*RenameResult, error) {
+	fromPath, err := w.resolveProtoFile(from)
+	if err != nil {
+		return nil, err
+	}
+	// The proto stays in the source root it lives in, rather than in the
+	// pb directory new paths resolve to
+	root := w.sourceRootOf(fromPath)
+	if filepath.Ext(to) != ".proto" {
+		return nil, fmt.Errorf("%s is not a .proto file", to)
+	}
+	toPath, err := resolveInside(root, to, "proto path", "the source root of "+from)
+	if err != nil {
+		return nil, err
+	}
+	if w.sourceRootOf(toPath) != root {
+		return nil, fmt.Errorf("%s would move %s into another buf module", to, from)
+	}
+	from, to = cleanProtoPath(from), cleanProtoPath(to)
+	if from == to {
+		return nil, fmt.Errorf("%s is already named %s", from, to)
+	}
+	if _, err := os.Stat(fromPath); err != nil {
+		return nil, fmt.Errorf("renaming %s: %w", from, err)
+	}
+	if _, err := os.Stat(toPath); err == nil {
+		return nil, fmt.Errorf("%s already exists", to)
+	}
+	if _, err := os.Stat(w.protoPath(to)); err == nil {
+		return nil, fmt.Errorf("%s already exists", to)
+	}
+
+	// Find the importers before the move, while the graph still has the old name
+	graph, err := w.importers()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(toPath), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(fromPath, toPath); err != nil {
+		return nil, fmt.Errorf("renaming %s: %w", from, err)
+	}
+
+	result := &RenameResult{From: from, To: to, Updated: []string{}, Unchanged: []string{}}
+	quoted := regexp.QuoteMeta(from)
+	pattern := regexp.MustCompile(`(?m)^(\s*import\s+(?:public\s+|weak\s+)?)(?:"` + quoted + `"|'` + quoted + `')(\s*;)`)
+	for _, importer := range graph[from] {
+		// A proto importing itself is not valid, but follow its move anyway
+		if importer == from {
+			importer = to
+		}
+		path := w.protoPath(importer)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return result, err
+		}
+		rewritten := pattern.ReplaceAllFunc(content, func(match []byte) []byte {
+			m := pattern.FindSubmatch(match)
+			quote := match[len(m[1])]
+			return []byte(string(m[1]) + string(quote) + to + string(quote) + string(m[2]))
+		})
+		if string(rewritten) == string(content) {
+			result.Unchanged = append(result.Unchanged, importer)
+			continue
+		}
+		if err := os.WriteFile(path, rewritten, 0644); err != nil {
+			return result, fmt.Errorf("updating imports of %s: %w", importer, err)
+		}
+		result.Updated = append(result.Updated, importer)
+	}
+	sort.Strings(result.Updated)
+	sort.Strings(result.Unchanged)
+	return result, nil
+}
+
+// protoDefaults guesses the package and go_package for a new proto at
+// filename: those of another proto in the same directory, else a package
+// named after the directory and a go_package below the Go module of the
+// workspace output directory
+func (w *Workspace) protoDefaults(filename string) (pkg, goPkg string) {
+	dir := path.Dir(filename)
+	if files, err := w.ProtoFiles(); err == nil {
+		for _, file := range files {
+			if path.Dir(file) != dir || file == filename {
+				continue
+			}
+			content, err := os.ReadFile(w.protoPath(file))
+			if err != nil {
+				continue
+			}
+			if pkg, goPkg = scanProtoHeader(string(content)); pkg != "" && goPkg != "" {
+				return pkg, goPkg
+			}
+		}
+	}
+
+	if dir == "." {
+		pkg = protoIdentifier(strings.TrimSuffix(path.Base(filename), ".proto"))
+	} else {
+		parts := strings.Split(dir, "/")
+		for i, part := range parts {
+			parts[i] = protoIdentifier(part)
+		}
+		pkg = strings.Join(parts, ".")
+	}
+
+	module := w.Name
+	if content, err := os.ReadFile(filepath.Join(w.Root, "go.mod")); err == nil {
+		if m := goModulePattern.FindSubmatch(content); m != nil {
+			module = string(m[1])
+		}
+	}
+	goPkg = path.Join(module, filepath.ToSlash(w.Config.OutputDir))
+	if dir != "." {
+		goPkg = path.Join(goPkg, dir)
+	}
+	return pkg, goPkg
+}
+
+// goModulePattern matches the module directive of a go.mod file
+var goModulePattern = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+
+// protoIdentifier turns a file or directory name into a lower snake_case
+// proto identifier. Names that would start with a digit, or leave nothing,
+// are prefixed with p, so 2024 becomes p2024.
+func protoIdentifier(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r + 'a' - 'A')
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	id := strings.Trim(b.String(), "_")
+	if id == "" || id[0] >= '0' && id[0] <= '9' {
+		id = "p" + id
+	}
+	return id
+}
+
+// pascalCase turns a snake_case or kebab-case name into PascalCase
+func pascalCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case r == '_' || r == '-' || r == '.':
+			upper = true
+		case upper && r >= 'a' && r <= 'z':
+			b.WriteRune(r + 'A' - 'a')
+			upper = false
+		default:
+			b.WriteRune(r)
+			upper = false
+		}
+	}
+	return b.String()
+}
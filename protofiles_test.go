@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCreatePBFromTemplate checks that every built-in template compiles and
+// follows the package of its directory
+func TestCreatePBFromTemplate(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{
+		"shop/v1/order.proto": "syntax = \"proto3\";\npackage shop.v1;\noption go_package = \"example.com/shop/v1;shopv1\";\n",
+	})
+
+	for _, tmpl := range app.ListPBTemplates() {
+		name := "shop/v1/user_profile_" + tmpl.Name + ".proto"
+		content, err := app.CreatePBFromTemplate(name, tmpl.Name)
+		if err != nil {
+			t.Fatalf("%s: %v", tmpl.Name, err)
+		}
+		if !strings.Contains(content, "package shop.v1;") || !strings.Contains(content, `option go_package = "example.com/shop/v1;shopv1";`) {
+			t.Errorf("%s does not follow the directory package:\n%s", tmpl.Name, content)
+		}
+		if diags, compiled := ws.Validate(context.Background(), []string{name}, nil); compiled == nil {
+			t.Errorf("%s does not compile: %v", tmpl.Name, diags)
+		}
+		if _, err := app.CreatePBFromTemplate(name, tmpl.Name); err == nil {
+			t.Errorf("%s: overwrote an existing file", tmpl.Name)
+		}
+	}
+
+	if _, err := app.CreatePBFromTemplate("other.proto", "missing"); err == nil {
+		t.Error("unknown template accepted")
+	}
+}
+
+// TestProtoIdentifier checks that names become valid proto identifiers,
+// and that protos created in numeric directories compile
+func TestProtoIdentifier(t *testing.T) {
+	for name, want := range map[string]string{
+		"UserProfile": "user_profile",
+		"order-items": "order_items",
+		"v1":          "v1",
+		"2024":        "p2024",
+		"_2x":         "p2x",
+		"--":          "p",
+	} {
+		if got := protoIdentifier(name); got != want {
+			t.Errorf("protoIdentifier(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := app.ListPBTemplates()[0].Name
+	for name, pkg := range map[string]string{"2024/report.proto": "p2024", "v1/2/report.proto": "v1.p2"} {
+		content, err := app.CreatePBFromTemplate(name, tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(content, "package "+pkg+";") {
+			t.Errorf("%s does not use package %s:\n%s", name, pkg, content)
+		}
+		if diags, compiled := ws.Validate(context.Background(), []string{name}, nil); compiled == nil {
+			t.Errorf("%s does not compile: %v", name, diags)
+		}
+	}
+}
+
+// TestRenamePB checks that renaming a proto rewrites the imports of the
+// protos referencing it, and that deleting reports remaining importers
+func TestRenamePB(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{
+		"common.proto": "syntax = \"proto3\";\npackage demo;\nmessage Common {}\n",
+		"a.proto":      "syntax = \"proto3\";\npackage demo;\nimport \"common.proto\";\nmessage A { Common c = 1; }\n",
+		"sub/b.proto":  "syntax = \"proto3\";\npackage demo;\nimport public \"common.proto\";\n// import \"common.proto\"; stays as is\nmessage B {}\n",
+		"c.proto":      "syntax = \"proto3\";\npackage demo;\nimport 'common.proto';\nmessage C { Common c = 1; }\n",
+		"d.proto":      "syntax = \"proto3\";\npackage demo;\nimport \"common.proto\" // legacy\n  ;\nmessage D {}\n",
+	})
+
+	result, err := app.RenamePB("common.proto", "shared/common.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.proto", "c.proto", "sub/b.proto"}; !reflect.DeepEqual(result.Updated, want) {
+		t.Errorf("updated = %v, want %v", result.Updated, want)
+	}
+	if want := []string{"d.proto"}; !reflect.DeepEqual(result.Unchanged, want) {
+		t.Errorf("unchanged = %v, want %v", result.Unchanged, want)
+	}
+	c, err := os.ReadFile(filepath.Join(ws.PBPath(), "c.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(c), "import 'shared/common.proto';") {
+		t.Errorf("c.proto import not rewritten:\n%s", c)
+	}
+	b, err := os.ReadFile(filepath.Join(ws.PBPath(), "sub", "b.proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "import public \"shared/common.proto\";") || !strings.Contains(string(b), "// import \"common.proto\"; stays") {
+		t.Errorf("sub/b.proto imports not rewritten correctly:\n%s", b)
+	}
+	if diags, compiled := ws.Validate(context.Background(), []string{"a.proto", "sub/b.proto"}, nil); compiled == nil {
+		t.Errorf("renamed workspace does not compile: %v", diags)
+	}
+
+	if _, err := app.RenamePB("a.proto", "sub/b.proto"); err == nil {
+		t.Error("rename over an existing file accepted")
+	}
+
+	importers, err := app.DeletePB("shared/common.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.proto", "c.proto", "sub/b.proto"}; !reflect.DeepEqual(importers, want) {
+		t.Errorf("importers = %v, want %v", importers, want)
+	}
+	if _, err := os.Stat(filepath.Join(ws.PBPath(), "shared", "common.proto")); !os.IsNotExist(err) {
+		t.Errorf("shared/common.proto still exists: %v", err)
+	}
+}
+
+// TestRenamePB_Module checks that a proto renamed in a buf module other
+// than the pb directory stays in its module
+func TestRenamePB_Module(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"buf.yaml":          "version: v2\nmodules:\n  - path: proto\n  - path: api\n",
+		"proto/demo.proto":  "syntax = \"proto3\";\npackage demo;\nmessage Demo {}\n",
+		"api/api/old.proto": "syntax = \"proto3\";\npackage api;\nmessage Old {}\n",
+	})
+	app := NewApp()
+	if _, err := app.OpenWorkspace(root); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.RenamePB("api/old.proto", "api/new.proto"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "api", "api", "new.proto")); err != nil {
+		t.Errorf("renamed proto not in its module: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "proto", "api", "new.proto")); !os.IsNotExist(err) {
+		t.Errorf("renamed proto moved into the pb directory: %v", err)
+	}
+
+	if _, err := app.RenamePB("api/new.proto", "demo.proto"); err == nil {
+		t.Error("rename onto a proto of another module accepted")
+	}
+}
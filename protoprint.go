@@ -0,0 +1,542 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maxFieldNumber is the largest field number, written as max in ranges
+const maxFieldNumber = 536870911
+
+// printProto returns the .proto source of fd. Custom options are decoded
+// with types; leading comments are kept when fd has source info. The
+// source is formatted with formatProto.
+func printProto(fd protoreflect.FileDescriptor, types typeResolver) (string, error) {
+	p := &descriptorPrinter{fd: fd, types: types}
+	p.file()
+	formatted, err := formatProto(p.b.String())
+	if err != nil {
+		return "", fmt.Errorf("printing %s: %w", fd.Path(), err)
+	}
+	return formatted, nil
+}
+
+// descriptorPrinter writes the declarations of a file descriptor
+type descriptorPrinter struct {
+	b        strings.Builder
+	fd       protoreflect.FileDescriptor
+	types    typeResolver
+	indent   int
+	packages map[protoreflect.FullName]bool
+}
+
+// line writes an indented line
+func (p *descriptorPrinter) line(format string, args ...interface{}) {
+	p.b.WriteString(strings.Repeat("  ", p.indent))
+	fmt.Fprintf(&p.b, format, args...)
+	p.b.WriteByte('\n')
+}
+
+// comment writes the leading comment of d
+func (p *descriptorPrinter) comment(d protoreflect.Descriptor) {
+	comment := p.fd.SourceLocations().ByDescriptor(d).LeadingComments
+	if comment == "" {
+		return
+	}
+	for _, l := range strings.Split(strings.TrimSuffix(comment, "\n"), "\n") {
+		p.line("//%s", strings.TrimRight(l, " \t"))
+	}
+}
+
+// editions reports whether the file uses editions syntax
+func (p *descriptorPrinter) editions() bool {
+	return p.fd.Syntax() == protoreflect.Editions
+}
+
+func (p *descriptorPrinter) file() {
+	switch p.fd.Syntax() {
+	case protoreflect.Editions:
+		edition := protodesc.ToFileDescriptorProto(p.fd).GetEdition()
+		p.line("edition = %q;", strings.TrimPrefix(edition.String(), "EDITION_"))
+	case protoreflect.Proto3:
+		p.line(`syntax = "proto3";`)
+	default:
+		p.line(`syntax = "proto2";`)
+	}
+	if p.fd.Package() != "" {
+		p.line("package %s;", p.fd.Package())
+	}
+	imports := p.fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		imp := imports.Get(i)
+		switch {
+		case imp.IsPublic:
+			p.line("import public %q;", imp.Path())
+		case imp.IsWeak:
+			p.line("import weak %q;", imp.Path())
+		default:
+			p.line("import %q;", imp.Path())
+		}
+	}
+	p.options(p.fd.Package(), p.fd.Options())
+
+	for i := 0; i < p.fd.Enums().Len(); i++ {
+		p.enum(p.fd.Enums().Get(i))
+	}
+	for i := 0; i < p.fd.Messages().Len(); i++ {
+		p.message(p.fd.Messages().Get(i))
+	}
+	p.extensions(p.fd.Package(), p.fd.Extensions())
+	for i := 0; i < p.fd.Services().Len(); i++ {
+		p.service(p.fd.Services().Get(i))
+	}
+}
+
+// options writes the options of a declaration as option statements
+func (p *descriptorPrinter) options(scope protoreflect.FullName, opts proto.Message) {
+	for _, option := range p.optionList(scope, opts) {
+		p.line("option %s;", option)
+	}
+}
+
+// compactOptions returns the options of a field or value in brackets, led
+// by the pseudo-options in extra, or nothing when there are none
+func (p *descriptorPrinter) compactOptions(scope protoreflect.FullName, opts proto.Message, extra ...string) string {
+	options := append(extra, p.optionList(scope, opts)...)
+	if len(options) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(options, ", ") + "]"
+}
+
+// optionList returns the options set in opts as "name = value". Repeated
+// options are set once per element.
+func (p *descriptorPrinter) optionList(scope protoreflect.FullName, opts proto.Message) []string {
+	parsed := resolveOptions(opts, p.types)
+	if parsed == nil {
+		return nil
+	}
+	options, _ := p.optionFields(scope, "", parsed, false)
+	return options
+}
+
+// optionFields returns the fields set in m as options named after prefix.
+// When flat is set only singular fields are allowed, and ok reports
+// whether m could be written that way.
+func (p *descriptorPrinter) optionFields(scope protoreflect.FullName, prefix string, m protoreflect.Message, flat bool) (options []string, ok bool) {
+	var fields []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		fields = append(fields, fd)
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Number() < fields[j].Number() })
+
+	for _, fd := range fields {
+		name := prefix + string(fd.Name())
+		if fd.IsExtension() {
+			name = prefix + "(" + p.typeRef(scope, fd.FullName()) + ")"
+		}
+		v := m.Get(fd)
+		switch {
+		case fd.IsList() || fd.IsMap():
+			// Options are never maps, only fields of message literals
+			if flat || fd.IsMap() {
+				return nil, false
+			}
+			for i := 0; i < v.List().Len(); i++ {
+				options = append(options, name+" = "+p.value(fd, v.List().Get(i)))
+			}
+		case fd.Message() != nil:
+			// Messages of plain fields with only singular fields read better
+			// field by field, as in features.field_presence = IMPLICIT;
+			// extensions keep their literal, as (google.api.http) = { ... }
+			if fd.IsExtension() && !flat {
+				options = append(options, name+" = "+p.value(fd, v))
+			} else if nested, ok := p.optionFields(scope, name+".", v.Message(), true); ok && len(nested) > 0 {
+				options = append(options, nested...)
+			} else if flat {
+				return nil, false
+			} else {
+				options = append(options, name+" = "+p.value(fd, v))
+			}
+		default:
+			options = append(options, name+" = "+p.value(fd, v))
+		}
+	}
+	return options, true
+}
+
+// value returns the source form of a singular option value. Messages are
+// written as text format literals.
+func (p *descriptorPrinter) value(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		text, err := prototext.MarshalOptions{Resolver: p.types}.Marshal(v.Message().Interface())
+		if err != nil {
+			return "{}"
+		}
+		return "{ " + string(text) + " }"
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	case protoreflect.StringKind:
+		return quoteProto([]byte(v.String()))
+	case protoreflect.BytesKind:
+		return quoteProto(v.Bytes())
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return formatFloat(v.Float())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// formatFloat writes a float literal, with inf and nan by name
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// quoteProto quotes a string or bytes literal. Printable ASCII and UTF-8
+// are kept, other bytes escaped in octal.
+func quoteProto(s []byte) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, c := range s {
+		switch c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\%03o`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// typeRef names a type or extension as seen from scope: the shortest name
+// relative to an enclosing scope that no declaration or package in a
+// nearer scope captures, or the fully qualified name
+func (p *descriptorPrinter) typeRef(scope, name protoreflect.FullName) string {
+	for outer := scope; ; outer = outer.Parent() {
+		rel := string(name)
+		if outer != "" {
+			if !strings.HasPrefix(rel, string(outer)+".") {
+				continue
+			}
+			rel = rel[len(outer)+1:]
+		}
+		first := protoreflect.Name(strings.SplitN(rel, ".", 2)[0])
+		captured := false
+		for s := scope; s != outer; s = s.Parent() {
+			if p.declared(s.Append(first)) {
+				captured = true
+				break
+			}
+		}
+		if !captured {
+			return rel
+		}
+		if outer == "" {
+			return "." + string(name)
+		}
+	}
+}
+
+// declared reports whether name is a declaration or a package, or a
+// prefix of one, known to the file
+func (p *descriptorPrinter) declared(name protoreflect.FullName) bool {
+	if _, err := p.types.files.FindDescriptorByName(name); err == nil {
+		return true
+	}
+	if p.packages == nil {
+		p.packages = make(map[protoreflect.FullName]bool)
+		for _, f := range transitiveFiles([]protoreflect.FileDescriptor{p.fd}) {
+			for pkg := f.Package(); pkg != ""; pkg = pkg.Parent() {
+				p.packages[pkg] = true
+			}
+		}
+	}
+	return p.packages[name]
+}
+
+func (p *descriptorPrinter) enum(ed protoreflect.EnumDescriptor) {
+	p.comment(ed)
+	p.line("enum %s {", ed.Name())
+	p.indent++
+	scope := ed.FullName().Parent()
+	p.options(scope, ed.Options())
+	for i := 0; i < ed.Values().Len(); i++ {
+		vd := ed.Values().Get(i)
+		p.comment(vd)
+		p.line("%s = %d%s;", vd.Name(), vd.Number(), p.compactOptions(scope, vd.Options()))
+	}
+
+	var reserved []string
+	for i := 0; i < ed.ReservedRanges().Len(); i++ {
+		r := ed.ReservedRanges().Get(i)
+		reserved = append(reserved, reservedRange(int64(r[0]), int64(r[1]), math.MaxInt32))
+	}
+	p.reserved(reserved, ed.ReservedNames())
+	p.indent--
+	p.line("}")
+}
+
+// reservedRange writes an inclusive range of numbers
+func reservedRange(start, end, max int64) string {
+	switch {
+	case start == end:
+		return strconv.FormatInt(start, 10)
+	case end == max:
+		return fmt.Sprintf("%d to max", start)
+	}
+	return fmt.Sprintf("%d to %d", start, end)
+}
+
+// reserved writes reserved numbers and names. Names are identifiers in
+// editions and strings before.
+func (p *descriptorPrinter) reserved(ranges []string, names protoreflect.Names) {
+	if len(ranges) > 0 {
+		p.line("reserved %s;", strings.Join(ranges, ", "))
+	}
+	if names.Len() == 0 {
+		return
+	}
+	quoted := make([]string, names.Len())
+	for i := range quoted {
+		quoted[i] = string(names.Get(i))
+		if !p.editions() {
+			quoted[i] = strconv.Quote(quoted[i])
+		}
+	}
+	p.line("reserved %s;", strings.Join(quoted, ", "))
+}
+
+// isGroup reports whether f is written as a proto2 group, whose message is
+// declared by the field
+func (p *descriptorPrinter) isGroup(f protoreflect.FieldDescriptor) bool {
+	return f.Kind() == protoreflect.GroupKind && !p.editions()
+}
+
+func (p *descriptorPrinter) message(md protoreflect.MessageDescriptor) {
+	p.comment(md)
+	p.line("message %s {", md.Name())
+	p.messageBody(md)
+	p.line("}")
+}
+
+// messageBody writes the declarations of a message or group
+func (p *descriptorPrinter) messageBody(md protoreflect.MessageDescriptor) {
+	p.indent++
+	defer func() { p.indent-- }()
+	p.options(md.FullName(), md.Options())
+
+	groups := make(map[protoreflect.FullName]bool)
+	printed := make(map[protoreflect.OneofDescriptor]bool)
+	for i := 0; i < md.Fields().Len(); i++ {
+		f := md.Fields().Get(i)
+		if p.isGroup(f) {
+			groups[f.Message().FullName()] = true
+		}
+		o := f.ContainingOneof()
+		if o == nil || o.IsSynthetic() {
+			p.field(md.FullName(), f)
+			continue
+		}
+		if printed[o] {
+			continue
+		}
+		printed[o] = true
+		p.comment(o)
+		p.line("oneof %s {", o.Name())
+		p.indent++
+		p.options(md.FullName(), o.Options())
+		for j := 0; j < o.Fields().Len(); j++ {
+			p.field(md.FullName(), o.Fields().Get(j))
+		}
+		p.indent--
+		p.line("}")
+	}
+
+	for i := 0; i < md.Messages().Len(); i++ {
+		nested := md.Messages().Get(i)
+		if !nested.IsMapEntry() && !groups[nested.FullName()] {
+			p.message(nested)
+		}
+	}
+	for i := 0; i < md.Enums().Len(); i++ {
+		p.enum(md.Enums().Get(i))
+	}
+
+	for i := 0; i < md.ExtensionRanges().Len(); i++ {
+		r := md.ExtensionRanges().Get(i)
+		p.line("extensions %s%s;", reservedRange(int64(r[0]), int64(r[1])-1, maxFieldNumber),
+			p.compactOptions(md.FullName(), md.ExtensionRangeOptions(i)))
+	}
+	var reserved []string
+	for i := 0; i < md.ReservedRanges().Len(); i++ {
+		r := md.ReservedRanges().Get(i)
+		reserved = append(reserved, reservedRange(int64(r[0]), int64(r[1])-1, maxFieldNumber))
+	}
+	p.reserved(reserved, md.ReservedNames())
+	p.extensions(md.FullName(), md.Extensions())
+}
+
+// field writes a field declared in scope
+func (p *descriptorPrinter) field(scope protoreflect.FullName, f protoreflect.FieldDescriptor) {
+	p.comment(f)
+	var extra []string
+	if f.HasDefault() {
+		extra = append(extra, "default = "+p.defaultValue(f))
+	}
+	if !f.IsExtension() && f.HasJSONName() && f.JSONName() != jsonCamelCase(string(f.Name())) {
+		extra = append(extra, "json_name = "+strconv.Quote(f.JSONName()))
+	}
+	options := p.compactOptions(scope, f.Options(), extra...)
+
+	if f.IsMap() {
+		p.line("map<%s, %s> %s = %d%s;", p.fieldType(scope, f.MapKey()), p.fieldType(scope, f.MapValue()), f.Name(), f.Number(), options)
+		return
+	}
+	label := p.label(f)
+	if p.isGroup(f) {
+		p.line("%sgroup %s = %d%s {", label, f.Message().Name(), f.Number(), options)
+		p.messageBody(f.Message())
+		p.line("}")
+		return
+	}
+	p.line("%s%s %s = %d%s;", label, p.fieldType(scope, f), f.Name(), f.Number(), options)
+}
+
+// label returns the label keyword of a field, with a trailing space
+func (p *descriptorPrinter) label(f protoreflect.FieldDescriptor) string {
+	if f.IsList() {
+		return "repeated "
+	}
+	if o := f.ContainingOneof(); o != nil && !o.IsSynthetic() {
+		return ""
+	}
+	switch p.fd.Syntax() {
+	case protoreflect.Proto2:
+		if f.Cardinality() == protoreflect.Required {
+			return "required "
+		}
+		return "optional "
+	case protoreflect.Proto3:
+		if f.HasOptionalKeyword() {
+			return "optional "
+		}
+	}
+	return ""
+}
+
+// fieldType returns the type of a field as written in scope
+func (p *descriptorPrinter) fieldType(scope protoreflect.FullName, f protoreflect.FieldDescriptor) string {
+	switch {
+	case f.Message() != nil:
+		return p.typeRef(scope, f.Message().FullName())
+	case f.Enum() != nil:
+		return p.typeRef(scope, f.Enum().FullName())
+	}
+	return f.Kind().String()
+}
+
+// defaultValue returns the explicit default of a proto2 field
+func (p *descriptorPrinter) defaultValue(f protoreflect.FieldDescriptor) string {
+	if f.Kind() == protoreflect.EnumKind {
+		return string(f.DefaultEnumValue().Name())
+	}
+	return p.value(f, f.Default())
+}
+
+// jsonCamelCase is the JSON name protoc derives from a field name
+func jsonCamelCase(name string) string {
+	var b strings.Builder
+	upper := false
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '_':
+			upper = true
+			continue
+		case upper && 'a' <= c && c <= 'z':
+			c -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// extensions writes extension fields declared in scope, one extend block
+// per run of fields with the same extendee
+func (p *descriptorPrinter) extensions(scope protoreflect.FullName, exts protoreflect.ExtensionDescriptors) {
+	for i := 0; i < exts.Len(); {
+		extendee := exts.Get(i).ContainingMessage().FullName()
+		p.line("extend %s {", p.typeRef(scope, extendee))
+		p.indent++
+		for ; i < exts.Len() && exts.Get(i).ContainingMessage().FullName() == extendee; i++ {
+			p.field(scope, exts.Get(i))
+		}
+		p.indent--
+		p.line("}")
+	}
+}
+
+func (p *descriptorPrinter) service(sd protoreflect.ServiceDescriptor) {
+	p.comment(sd)
+	p.line("service %s {", sd.Name())
+	p.indent++
+	p.options(sd.FullName(), sd.Options())
+	for i := 0; i < sd.Methods().Len(); i++ {
+		m := sd.Methods().Get(i)
+		p.comment(m)
+		input, output := p.typeRef(sd.FullName(), m.Input().FullName()), p.typeRef(sd.FullName(), m.Output().FullName())
+		if m.IsStreamingClient() {
+			input = "stream " + input
+		}
+		if m.IsStreamingServer() {
+			output = "stream " + output
+		}
+		options := p.optionList(sd.FullName(), m.Options())
+		if len(options) == 0 {
+			p.line("rpc %s (%s) returns (%s);", m.Name(), input, output)
+			continue
+		}
+		p.line("rpc %s (%s) returns (%s) {", m.Name(), input, output)
+		p.indent++
+		for _, option := range options {
+			p.line("option %s;", option)
+		}
+		p.indent--
+		p.line("}")
+	}
+	p.indent--
+	p.line("}")
+}
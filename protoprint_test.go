@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// TestPrintProto prints compiled protos back to source. The inputs are in
+// canonical form, so printing them must give them back unchanged.
+func TestPrintProto(t *testing.T) {
+	files := map[string]string{
+		"legacy.proto": `syntax = "proto2";
+
+package legacy;
+
+option java_package = "com.example.legacy";
+
+import "google/protobuf/descriptor.proto";
+
+enum Level {
+  option allow_alias = true;
+
+  LOW = 0;
+  MIN = 0 [deprecated = true];
+  reserved 5 to 10, 100 to max;
+  reserved "GONE";
+}
+
+// Thing uses every proto2 construct
+message Thing {
+  option (label) = "thing";
+  option (weights) = 1;
+  option (weights) = 2;
+
+  // the name
+  required string name = 1 [default = "a\"b\n"];
+  optional int32 size = 2 [default = -5, json_name = "sz"];
+  optional double ratio = 3 [default = inf];
+  optional Kind kind = 4 [default = KIND_B];
+  repeated int32 nums = 5 [packed = true];
+  optional group Result = 6 {
+    optional string url = 7;
+  }
+  oneof choice {
+    string text = 8;
+    group Inner = 9 {
+      optional int32 x = 10;
+    }
+  }
+  map<string, Thing> children = 11;
+  optional Thing self = 12;
+  optional legacy.Thing top = 13;
+  message Thing {
+    optional int32 y = 1;
+  }
+  enum Kind {
+    KIND_A = 0;
+    KIND_B = 1;
+  }
+  extensions 100 to 199;
+  extensions 1000 to max;
+  reserved 20, 30 to 40;
+  reserved "old", "older";
+  extend legacy.Thing {
+    optional int32 ext = 100;
+  }
+}
+
+extend google.protobuf.MessageOptions {
+  optional string label = 50001;
+  repeated int32 weights = 50002;
+}
+`,
+		"svc.proto": `syntax = "proto3";
+
+package svc.v1;
+
+import "google/api/annotations.proto";
+import "legacy.proto";
+
+message Req {
+  optional string id = 1;
+  legacy.Thing thing = 2;
+}
+
+// Svc serves
+service Svc {
+  option deprecated = true;
+
+  rpc Get (Req) returns (stream Req) {
+    option idempotency_level = NO_SIDE_EFFECTS;
+    option (google.api.http) = {
+      get: "/v1/{id}"
+      additional_bindings: {
+        post: "/x"
+        body: "*"
+      }
+    };
+  }
+  rpc Put (stream Req) returns (Req);
+}
+`,
+		"ed.proto": `edition = "2023";
+
+package ed;
+
+option features.field_presence = IMPLICIT;
+
+message M {
+  int32 a = 1 [features.field_presence = EXPLICIT];
+  M child = 2 [features.message_encoding = DELIMITED];
+  reserved foo;
+}
+`,
+	}
+	ws, err := LoadWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), files)
+	compiled, err := ws.CompileAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := typeResolver{files: compiled.AsResolver()}
+	for _, fd := range compiled {
+		got, err := printProto(fd, types)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := files[fd.Path()]; got != want {
+			t.Errorf("printProto(%s) =\n%s\nwant\n%s", fd.Path(), got, want)
+		}
+	}
+}
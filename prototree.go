@@ -0,0 +1,460 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// protoPackagePattern matches a proto package declaration
+	protoPackagePattern = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+	// protoGoPackagePattern matches the go_package file option
+	protoGoPackagePattern = regexp.MustCompile(`(?m)^\s*option\s+go_package\s*=\s*"([^"]*)"\s*;`)
+	// protoImportPattern matches import statements, including public and weak
+	// ones, with the path in double or single quotes
+	protoImportPattern = regexp.MustCompile(`(?m)^\s*import\s+(?:public\s+|weak\s+)?(?:"([^"]+)"|'([^']+)')\s*;`)
+	// protoLineCommentPattern matches // comments so declarations inside them are ignored
+	protoLineCommentPattern = regexp.MustCompile(`//[^\n]*`)
+)
+
+// ProtoNode is a directory or proto file in the workspace pb tree
+type ProtoNode struct {
+	Name      string       `json:"name"`
+	Path      string       `json:"path"`
+	IsDir     bool         `json:"isDir"`
+	Package   string       `json:"package,omitempty"`
+	GoPackage string       `json:"goPackage,omitempty"`
+	Size      int64        `json:"size"`
+	Modified  string       `json:"modified"`
+	Children  []*ProtoNode `json:"children,omitempty"`
+}
+
+// resolvePB maps a slash-separated import path to an absolute path in the
+// source root holding it, rejecting paths that would escape that root
+func (w *Workspace) resolvePB(rel string) (string, error) {
+	return resolveInside(w.protoRoot(rel), rel, "proto path", "the pb directory")
+}
+
+// protoRoot returns the source root holding the proto at the slash-separated
+// import path rel, or the pb directory when no root holds it, as for new files
+func (w *Workspace) protoRoot(rel string) string {
+	name := filepath.FromSlash(rel)
+	for _, root := range w.sourceRoots() {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err == nil && w.sourceRootOf(path) == root && !w.excludedPath(path) {
+			return root
+		}
+	}
+	return w.PBPath()
+}
+
+// protoPath returns the absolute path of the proto at the import path rel,
+// which must already be validated
+func (w *Workspace) protoPath(rel string) string {
+	return filepath.Join(w.protoRoot(rel), filepath.FromSlash(rel))
+}
+
+// sourceRootOf returns the innermost source root holding path, or "" if
+// none does. Files of a module nested in another belong to the inner one.
+func (w *Workspace) sourceRootOf(path string) string {
+	owner := ""
+	for _, root := range w.sourceRoots() {
+		if isWithin(path, root) && (owner == "" || isWithin(root, owner)) {
+			owner = root
+		}
+	}
+	return owner
+}
+
+// nestedRoot reports whether dir is a source root other than root, whose
+// protos are listed under that root instead
+func (w *Workspace) nestedRoot(dir, root string) bool {
+	return dir != root && w.sourceRootOf(dir) == dir
+}
+
+// resolveInside maps a slash-separated path relative to dir to an absolute
+// path, rejecting paths that escape dir, also through symlinks. kind and
+// dirName name the path and dir in errors.
+func resolveInside(dir, rel, kind, dirName string) (string, error) {
+	if rel == "" {
+		return "", fmt.Errorf("empty %s", kind)
+	}
+	if filepath.IsAbs(rel) || strings.HasPrefix(rel, "/") || strings.HasPrefix(rel, `\`) {
+		return "", fmt.Errorf("%s %s must be relative", kind, rel)
+	}
+
+	cleaned := filepath.Clean(filepath.FromSlash(rel))
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s %s escapes %s", kind, rel, dirName)
+	}
+	abs := filepath.Join(dir, cleaned)
+
+	// Reject symlinks inside dir that point outside of it, including
+	// symlinked directories above files that do not exist yet
+	resolvedDir, err := evalExistingSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := evalExistingSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w", kind, rel, err)
+	}
+	if inside, err := filepath.Rel(resolvedDir, resolved); err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s %s escapes %s", kind, rel, dirName)
+	}
+	return abs, nil
+}
+
+// evalExistingSymlinks resolves the symlinks of the nearest existing
+// ancestor of path and rejoins the missing rest. Dangling symlinks are
+// rejected, since writing through them creates their target.
+func evalExistingSymlinks(path string) (string, error) {
+	missing := ""
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if _, lstatErr := os.Lstat(path); lstatErr == nil || !os.IsNotExist(err) {
+			return "", fmt.Errorf("resolving %s: %w", path, err)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(path, missing), nil
+		}
+		missing = filepath.Join(filepath.Base(path), missing)
+		path = parent
+	}
+}
+
+// toolDirs returns the output, include and vendored dependency directories
+// that lie inside a source root, as when a root-level buf.yaml makes the
+// workspace root the pb directory. Their protos are imports or generated,
+// not workspace protos.
+func (w *Workspace) toolDirs() []string {
+	candidates := append(w.outputDirs(), w.IncludePath())
+	if w.Buf != nil {
+		for _, dep := range w.Buf.Deps {
+			if dep.Dir != "" {
+				candidates = append(candidates, w.path(filepath.FromSlash(dep.Dir)))
+			}
+		}
+	}
+
+	roots := w.sourceRoots()
+	var dirs []string
+	for _, dir := range candidates {
+		inside, holdsRoot := false, false
+		for _, root := range roots {
+			inside = inside || isWithin(dir, root)
+			holdsRoot = holdsRoot || isWithin(root, dir)
+		}
+		if inside && !holdsRoot {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// cleanProtoPath normalizes a pb-relative path to the form protos are compiled under
+func cleanProtoPath(rel string) string {
+	return filepath.ToSlash(filepath.Clean(filepath.FromSlash(rel)))
+}
+
+// resolveProtoFile is resolvePB restricted to .proto files
+func (w *Workspace) resolveProtoFile(rel string) (string, error) {
+	if filepath.Ext(rel) != ".proto" {
+		return "", fmt.Errorf("%s is not a .proto file", rel)
+	}
+	return w.resolvePB(rel)
+}
+
+// ProtoFiles returns every .proto file of the source roots as slash-separated
+// import paths relative to their root, sorted. A path found in several roots
+// is listed once, for the first root.
+func (w *Workspace) ProtoFiles() ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+
+	for i, root := range w.sourceRoots() {
+		if _, err := os.Stat(root); i > 0 && os.IsNotExist(err) {
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != root && (skipTreeDir(d.Name()) || w.excludedPath(path) || w.nestedRoot(path, root)) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(d.Name()) != ".proto" || w.excludedPath(path) {
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			if name := filepath.ToSlash(rel); !seen[name] {
+				seen[name] = true
+				files = append(files, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// skipTreeDir reports whether a directory is excluded from the proto tree
+func skipTreeDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules"
+}
+
+// excludedPath reports whether the buf config excludes path, or whether it
+// lies in a directory pb-tool reads or writes itself
+func (w *Workspace) excludedPath(path string) bool {
+	for _, dir := range w.toolDirs() {
+		if isWithin(path, dir) {
+			return true
+		}
+	}
+	if w.Buf == nil {
+		return false
+	}
+	rel, err := filepath.Rel(w.Root, path)
+	if err != nil {
+		return false
+	}
+	return w.Buf.excluded(filepath.ToSlash(rel))
+}
+
+// buildProtoTree walks dir and returns its node, or nil if it contains no
+// protos. Source roots nested in dir are left to their own tree.
+func (w *Workspace) buildProtoTree(dir, rel string) (*ProtoNode, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &ProtoNode{
+		Name:     filepath.Base(dir),
+		Path:     filepath.ToSlash(rel),
+		IsDir:    true,
+		Modified: info.ModTime().Format("2006-01-02 15:04:05"),
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		childRel := filepath.Join(rel, entry.Name())
+		childPath := filepath.Join(dir, entry.Name())
+		if w.excludedPath(childPath) {
+			continue
+		}
+
+		if entry.IsDir() {
+			if skipTreeDir(entry.Name()) || w.sourceRootOf(childPath) == childPath {
+				continue
+			}
+			child, err := w.buildProtoTree(childPath, childRel)
+			if err != nil {
+				return nil, err
+			}
+			if child != nil {
+				node.Children = append(node.Children, child)
+			}
+			continue
+		}
+
+		if filepath.Ext(entry.Name()) != ".proto" {
+			continue
+		}
+		child, err := readProtoNode(childPath, childRel)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+
+	if len(node.Children) == 0 && rel != "." {
+		return nil, nil
+	}
+
+	sortProtoNodes(node.Children)
+	return node, nil
+}
+
+// sortProtoNodes orders directories first, then files, each by name
+func sortProtoNodes(nodes []*ProtoNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		return a.Name < b.Name
+	})
+}
+
+// mergeProtoTree adds the children of from to into, merging directories of
+// the same name. Files already in into shadow those of from, as on import.
+func mergeProtoTree(into, from *ProtoNode) {
+	for _, child := range from.Children {
+		merged := false
+		for _, existing := range into.Children {
+			if existing.Name != child.Name {
+				continue
+			}
+			if existing.IsDir && child.IsDir {
+				mergeProtoTree(existing, child)
+			}
+			merged = true
+			break
+		}
+		if !merged {
+			into.Children = append(into.Children, child)
+		}
+	}
+	sortProtoNodes(into.Children)
+}
+
+// readProtoNode builds the node of a single proto file
+func readProtoNode(path, rel string) (*ProtoNode, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, goPkg := scanProtoHeader(string(content))
+	return &ProtoNode{
+		Name:      filepath.Base(path),
+		Path:      filepath.ToSlash(rel),
+		Package:   pkg,
+		GoPackage: goPkg,
+		Size:      info.Size(),
+		Modified:  info.ModTime().Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// scanProtoHeader extracts the package and go_package declarations of a proto
+func scanProtoHeader(content string) (pkg, goPkg string) {
+	content = protoLineCommentPattern.ReplaceAllString(content, "")
+	if m := protoPackagePattern.FindStringSubmatch(content); m != nil {
+		pkg = m[1]
+	}
+	if m := protoGoPackagePattern.FindStringSubmatch(content); m != nil {
+		goPkg = m[1]
+	}
+	return pkg, goPkg
+}
+
+// scanProtoImports returns the paths imported by a proto
+func scanProtoImports(content string) []string {
+	content = protoLineCommentPattern.ReplaceAllString(content, "")
+	var imports []string
+	for _, m := range protoImportPattern.FindAllStringSubmatch(content, -1) {
+		imports = append(imports, m[1]+m[2])
+	}
+	return imports
+}
+
+// importers maps every workspace proto to the workspace protos importing it
+func (w *Workspace) importers() (map[string][]string, error) {
+	files, err := w.ProtoFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	graph := make(map[string][]string)
+	for _, file := range files {
+		content, err := os.ReadFile(w.protoPath(file))
+		if err != nil {
+			return nil, err
+		}
+		for _, imp := range scanProtoImports(string(content)) {
+			graph[imp] = append(graph[imp], file)
+		}
+	}
+	return graph, nil
+}
+
+// Dependents returns the workspace protos importing file directly or
+// through other protos, sorted
+func (w *Workspace) Dependents(file string) ([]string, error) {
+	graph, err := w.importers()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{file: true}
+	queue := []string{file}
+	var dependents []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, importer := range graph[current] {
+			if !seen[importer] {
+				seen[importer] = true
+				dependents = append(dependents, importer)
+				queue = append(queue, importer)
+			}
+		}
+	}
+
+	sort.Strings(dependents)
+	return dependents, nil
+}
+
+// GetPBTree returns the recursive tree of directories and proto files of
+// the workspace source roots, merged by import path under the pb directory
+func (a *App) GetPBTree() (*ProtoNode, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	return ws.protoTree()
+}
+
+// protoTree returns the merged tree of the source roots
+func (w *Workspace) protoTree() (*ProtoNode, error) {
+	var tree *ProtoNode
+	for _, root := range w.sourceRoots() {
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			continue
+		}
+		node, err := w.buildProtoTree(root, ".")
+		if err != nil {
+			return nil, err
+		}
+		if tree == nil && root == w.PBPath() {
+			tree = node
+			continue
+		}
+		if tree == nil {
+			tree = &ProtoNode{Name: filepath.Base(w.PBPath()), Path: ".", IsDir: true}
+		}
+		mergeProtoTree(tree, node)
+	}
+	if tree == nil {
+		tree = &ProtoNode{Name: filepath.Base(w.PBPath()), Path: ".", IsDir: true}
+	}
+	return tree, nil
+}
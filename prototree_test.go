@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestResolvePB checks that proto paths cannot escape the pb directory
+func TestResolvePB(t *testing.T) {
+	ws, err := LoadWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"flat file", "example.proto", false},
+		{"nested file", "api/v1/foo/bar.proto", false},
+		{"dot segments inside", "api/../example.proto", false},
+		{"parent directory", "../main.go", true},
+		{"nested escape", "api/../../secret.proto", true},
+		{"absolute path", "/etc/passwd", true},
+		{"empty path", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ws.resolvePB(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolvePB(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestProtoTree checks recursive listing with package and go_package
+func TestProtoTree(t *testing.T) {
+	ws, err := LoadWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"example.proto":        "syntax = \"proto3\";\npackage example;\n",
+		"api/v1/foo/bar.proto": "syntax = \"proto3\";\n// package commented;\npackage api.v1.foo;\noption go_package = \"example.com/api/v1/foo\";\n",
+		"api/readme.txt":       "not a proto",
+		"empty/.keep":          "",
+	}
+	for name, content := range files {
+		path := filepath.Join(ws.PBPath(), filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	protos, err := ws.ProtoFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(protos) != 2 || protos[0] != "api/v1/foo/bar.proto" || protos[1] != "example.proto" {
+		t.Errorf("ProtoFiles = %v", protos)
+	}
+
+	tree, err := ws.buildProtoTree(ws.PBPath(), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Children) != 2 {
+		t.Fatalf("root children = %d, want 2 (api/ and example.proto)", len(tree.Children))
+	}
+
+	bar := tree.Children[0].Children[0].Children[0].Children[0]
+	if bar.Path != "api/v1/foo/bar.proto" {
+		t.Fatalf("nested node path = %s", bar.Path)
+	}
+	if bar.Package != "api.v1.foo" || bar.GoPackage != "example.com/api/v1/foo" {
+		t.Errorf("bar.proto package = %q, go_package = %q", bar.Package, bar.GoPackage)
+	}
+}
+
+// TestResolvePB_Symlinks checks that symlinks cannot lead writes out of the
+// pb directory, even to files that do not exist yet
+func TestResolvePB_Symlinks(t *testing.T) {
+	ws, err := LoadWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	writeFiles(t, ws.PBPath(), map[string]string{"inner/a.proto": "syntax = \"proto3\";\n"})
+	links := map[string]string{
+		"escape":         outside,
+		"inner-link":     filepath.Join(ws.PBPath(), "inner"),
+		"dangling.proto": filepath.Join(outside, "dangling.proto"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(ws.PBPath(), name)); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	for _, path := range []string{"escape/new.proto", "escape/sub/new.proto", "dangling.proto"} {
+		if _, err := ws.resolvePB(path); err == nil {
+			t.Errorf("resolvePB(%q) escaped the pb directory", path)
+		}
+	}
+	for _, path := range []string{"inner-link/a.proto", "inner-link/new.proto", "new/dir/new.proto"} {
+		if _, err := ws.resolvePB(path); err != nil {
+			t.Errorf("resolvePB(%q): %v", path, err)
+		}
+	}
+
+	app := NewApp()
+	if _, err := app.OpenWorkspace(ws.Root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.SavePB("escape/new.proto", "syntax = \"proto3\";\n", ""); err == nil {
+		t.Error("SavePB wrote through an escaping symlink")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.proto")); err == nil {
+		t.Error("file created outside the workspace")
+	}
+}
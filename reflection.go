@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// reflectionTimeout bounds the descriptor requests to a server
+const reflectionTimeout = 30 * time.Second
+
+// reflectionRequest is a request of the reflection protocol: the services
+// list, the file declaring a symbol, or a file by name
+type reflectionRequest struct {
+	listServices bool
+	symbol       string
+	filename     string
+}
+
+// reflectionReply carries the serialized files or service names returned
+// for a reflectionRequest
+type reflectionReply struct {
+	files    [][]byte
+	services []string
+}
+
+// reflectionStream sends a request on an open reflection stream and
+// waits for its reply
+type reflectionStream func(reflectionRequest) (*reflectionReply, error)
+
+// reflectionClient is an open reflection stream of either protocol
+// version, speaking v1 messages
+type reflectionClient interface {
+	Send(*reflectionv1.ServerReflectionRequest) error
+	Recv() (*reflectionv1.ServerReflectionResponse, error)
+}
+
+// reflectionV1 opens a stream of the v1 reflection protocol
+func reflectionV1(ctx context.Context, conn *grpc.ClientConn) (reflectionStream, error) {
+	stream, err := reflectionv1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newReflectionStream(stream), nil
+}
+
+// reflectionV1Alpha opens a stream of the v1alpha reflection protocol,
+// which older servers implement instead of v1
+func reflectionV1Alpha(ctx context.Context, conn *grpc.ClientConn) (reflectionStream, error) {
+	stream, err := reflectionv1alpha.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newReflectionStream(v1AlphaClient{stream}), nil
+}
+
+// v1AlphaClient converts the messages of a v1alpha stream from and to v1,
+// whose wire format is identical
+type v1AlphaClient struct {
+	stream reflectionv1alpha.ServerReflection_ServerReflectionInfoClient
+}
+
+// Send sends a v1 request as its v1alpha equivalent
+func (c v1AlphaClient) Send(req *reflectionv1.ServerReflectionRequest) error {
+	alpha := &reflectionv1alpha.ServerReflectionRequest{}
+	if err := convertMessage(req, alpha); err != nil {
+		return err
+	}
+	return c.stream.Send(alpha)
+}
+
+// Recv receives a v1alpha response as its v1 equivalent
+func (c v1AlphaClient) Recv() (*reflectionv1.ServerReflectionResponse, error) {
+	alpha, err := c.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	resp := &reflectionv1.ServerReflectionResponse{}
+	if err := convertMessage(alpha, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// convertMessage copies from into to, a message of the same wire format
+func convertMessage(from, to proto.Message) error {
+	data, err := proto.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, to)
+}
+
+// newReflectionStream sends each request on stream and waits for its reply
+func newReflectionStream(stream reflectionClient) reflectionStream {
+	return func(r reflectionRequest) (*reflectionReply, error) {
+		req := &reflectionv1.ServerReflectionRequest{}
+		switch {
+		case r.listServices:
+			req.MessageRequest = &reflectionv1.ServerReflectionRequest_ListServices{ListServices: "*"}
+		case r.symbol != "":
+			req.MessageRequest = &reflectionv1.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: r.symbol}
+		default:
+			req.MessageRequest = &reflectionv1.ServerReflectionRequest_FileByFilename{FileByFilename: r.filename}
+		}
+		if err := stream.Send(req); err != nil {
+			// The reason the stream broke is returned by Recv
+			if _, recvErr := stream.Recv(); recvErr != nil {
+				return nil, recvErr
+			}
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+		}
+		reply := &reflectionReply{files: resp.GetFileDescriptorResponse().GetFileDescriptorProto()}
+		for _, s := range resp.GetListServicesResponse().GetService() {
+			reply.services = append(reply.services, s.GetName())
+		}
+		return reply, nil
+	}
+}
+
+// reflectedFiles are the files pulled from a server by reflection, with
+// dynamic types for their messages and extensions
+type reflectedFiles struct {
+	*protoregistry.Files
+	*dynamicpb.Types
+}
+
+// serverDescriptors are the protos of a running server found by
+// reflection. protocol is v1 or v1alpha.
+type serverDescriptors struct {
+	target   string
+	protocol string
+	services []string
+	files    []protoreflect.FileDescriptor
+	types    typeResolver
+}
+
+// reflectServer asks the server at target for its services and the files
+// declaring them and their imports. The v1 protocol is tried first, then
+// v1alpha. Imports the server does not return are taken from the
+// descriptors linked into pb-tool, such as the well-known types.
+func reflectServer(ctx context.Context, target string, useTLS bool) (*serverDescriptors, error) {
+	conn, err := dial(target, useTLS)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(ctx, reflectionTimeout)
+	defer cancel()
+
+	server := &serverDescriptors{target: target, protocol: "v1"}
+	ask, err := reflectionV1(ctx, conn)
+	var reply *reflectionReply
+	if err == nil {
+		reply, err = ask(reflectionRequest{listServices: true})
+	}
+	if status.Code(err) == codes.Unimplemented {
+		server.protocol = "v1alpha"
+		if ask, err = reflectionV1Alpha(ctx, conn); err == nil {
+			reply, err = ask(reflectionRequest{listServices: true})
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing the services of %s: %w", target, err)
+	}
+	server.services = reply.services
+	sort.Strings(server.services)
+
+	protos := make(map[string]*descriptorpb.FileDescriptorProto)
+	add := func(files [][]byte) error {
+		for _, data := range files {
+			fdp := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(data, fdp); err != nil {
+				return fmt.Errorf("decoding a descriptor of %s: %w", target, err)
+			}
+			if _, ok := protos[fdp.GetName()]; !ok {
+				protos[fdp.GetName()] = fdp
+			}
+		}
+		return nil
+	}
+	for _, service := range server.services {
+		reply, err := ask(reflectionRequest{symbol: service})
+		if err == nil {
+			if err := add(reply.files); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// Some servers do not describe the services they take from their
+		// libraries, such as reflection itself
+		d, linkedErr := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+		if linkedErr != nil {
+			return nil, fmt.Errorf("finding the file of %s: %w", service, err)
+		}
+		fd := d.ParentFile()
+		if _, ok := protos[fd.Path()]; !ok {
+			protos[fd.Path()] = protodesc.ToFileDescriptorProto(fd)
+		}
+	}
+
+	// Servers usually send the imports along, ask for the missing ones
+	for missing := true; missing; {
+		missing = false
+		for _, fdp := range protos {
+			for _, dep := range fdp.GetDependency() {
+				if _, ok := protos[dep]; ok {
+					continue
+				}
+				missing = true
+				reply, err := ask(reflectionRequest{filename: dep})
+				if err == nil {
+					if err := add(reply.files); err != nil {
+						return nil, err
+					}
+				}
+				if _, ok := protos[dep]; ok {
+					continue
+				}
+				fd, linkedErr := protoregistry.GlobalFiles.FindFileByPath(dep)
+				if linkedErr != nil {
+					if err == nil {
+						err = linkedErr
+					}
+					return nil, fmt.Errorf("finding %s imported by %s: %w", dep, fdp.GetName(), err)
+				}
+				protos[dep] = protodesc.ToFileDescriptorProto(fd)
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fdp := range protos {
+		set.File = append(set.File, fdp)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("linking the descriptors of %s: %w", target, err)
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		server.files = append(server.files, fd)
+		return true
+	})
+	sort.Slice(server.files, func(i, j int) bool { return server.files[i].Path() < server.files[j].Path() })
+	server.types = typeResolver{files: reflectedFiles{Files: files, Types: dynamicpb.NewTypes(files)}}
+	return server, nil
+}
+
+// ownFiles returns the reflected files other than those linked into
+// pb-tool, such as the well-known types and the reflection protocol itself
+func (s *serverDescriptors) ownFiles() []protoreflect.FileDescriptor {
+	var own []protoreflect.FileDescriptor
+	for _, fd := range s.files {
+		if _, err := protoregistry.GlobalFiles.FindFileByPath(fd.Path()); err != nil {
+			own = append(own, fd)
+		}
+	}
+	return own
+}
+
+// requestTypes returns the types to build the request of req with: those
+// of the server when req uses reflection, else those of the workspace
+func (a *App) requestTypes(ctx context.Context, req InvokeRequest) (typeResolver, error) {
+	if !req.Reflection {
+		return a.workspaceTypes(ctx)
+	}
+	server, err := reflectServer(ctx, req.Target, req.TLS)
+	if err != nil {
+		return typeResolver{}, err
+	}
+	return server.types, nil
+}
+
+// DescribeServer pulls the protos of a running server by gRPC reflection
+// and returns their schema, as DescribeWorkspace does for the workspace
+func (a *App) DescribeServer(target string, useTLS bool) (*WorkspaceSchema, error) {
+	server, err := reflectServer(context.Background(), target, useTLS)
+	if err != nil {
+		return nil, err
+	}
+	b := &schemaBuilder{resolver: server.types}
+	return &WorkspaceSchema{Workspace: target, Packages: b.describe(server.ownFiles())}, nil
+}
+
+// ExportResult lists the protos written by ExportServerProtos and those
+// skipped, because the workspace has them already or resolves them as a
+// dependency
+type ExportResult struct {
+	Target   string   `json:"target"`
+	Protocol string   `json:"protocol"`
+	Written  []string `json:"written"`
+	Skipped  []string `json:"skipped"`
+}
+
+// ExportServerProtos pulls the protos of a running server by gRPC
+// reflection and writes them as .proto files into the workspace pb
+// directory. Existing files are only replaced when overwrite is set. The
+// exported files are compiled before anything is written.
+func (a *App) ExportServerProtos(target string, useTLS, overwrite bool) (*ExportResult, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	server, err := reflectServer(ctx, target, useTLS)
+	if err != nil {
+		return nil, err
+	}
+	result, sources, err := ws.exportProtos(server, overwrite)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) > 0 {
+		if _, err := ws.compile(ctx, result.Written, sources, nil); err != nil {
+			return nil, fmt.Errorf("the exported protos do not compile: %w", err)
+		}
+	}
+
+	for _, name := range result.Written {
+		filePath, err := ws.resolveProtoFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return nil, fmt.Errorf("creating directory for %s: %w", name, err)
+		}
+		if err := os.WriteFile(filePath, []byte(sources[name]), 0644); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", name, err)
+		}
+	}
+	return result, nil
+}
+
+// exportProtos prints the reflected files to write into the workspace,
+// keyed by their pb-relative path
+func (w *Workspace) exportProtos(server *serverDescriptors, overwrite bool) (*ExportResult, map[string]string, error) {
+	result := &ExportResult{Target: server.target, Protocol: server.protocol, Written: []string{}, Skipped: []string{}}
+	sources := make(map[string]string)
+	for _, fd := range server.files {
+		name := fd.Path()
+		if w.isDependency(name) {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		if _, err := w.resolveProtoFile(name); err != nil {
+			return nil, nil, err
+		}
+		if _, err := os.Stat(w.protoPath(name)); err == nil && !overwrite {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		source, err := printProto(fd, server.types)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources[name] = source
+		result.Written = append(result.Written, name)
+	}
+	return result, sources, nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+// reflectedChat is the chat service with a custom option, an HTTP binding
+// and an import, as served over reflection
+var reflectedChat = map[string]string{
+	"chat/options.proto": `syntax = "proto3";
+
+package chat;
+
+import "google/protobuf/descriptor.proto";
+
+extend google.protobuf.MethodOptions {
+  bool audited = 50001;
+}
+`,
+	"chat/chat.proto": `syntax = "proto3";
+
+package chat;
+
+import "chat/options.proto";
+import "google/api/annotations.proto";
+
+message Msg {
+  string text = 1;
+}
+
+service Chat {
+  rpc Echo (Msg) returns (Msg) {
+    option (audited) = true;
+    option (google.api.http) = {
+      post: "/v1/echo"
+      body: "*"
+    };
+  }
+  rpc Repeat (Msg) returns (stream Msg);
+  rpc Join (stream Msg) returns (Msg);
+  rpc Talk (stream Msg) returns (stream Msg);
+}
+`,
+}
+
+// serveReflectedChat serves chat.Chat with reflection, over both protocol
+// versions or only v1alpha
+func serveReflectedChat(t *testing.T, v1 bool) string {
+	t.Helper()
+	ws, err := LoadWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), reflectedChat)
+	compiled, err := ws.CompileAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := compiled.FindFileByPath("chat/chat.proto").Messages().ByName("Msg")
+	return serveChat(t, md, func(s *grpc.Server) {
+		opts := reflection.ServerOptions{Services: s, DescriptorResolver: compiled.AsResolver()}
+		if v1 {
+			reflectionv1.RegisterServerReflectionServer(s, reflection.NewServerV1(opts))
+		}
+		reflectionv1alpha.RegisterServerReflectionServer(s, reflection.NewServer(opts))
+	})
+}
+
+// TestDescribeServer browses and invokes a server known only by reflection
+func TestDescribeServer(t *testing.T) {
+	target := serveReflectedChat(t, true)
+	app := NewApp()
+
+	schema, err := app.DescribeServer(target, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The reflection service is linked into pb-tool and left out
+	if len(schema.Packages) != 1 || schema.Packages[0].Name != "chat" {
+		t.Fatalf("packages = %+v", schema.Packages)
+	}
+	chat := schema.Packages[0]
+	if !reflect.DeepEqual(chat.Files, []string{"chat/chat.proto", "chat/options.proto"}) || len(chat.Services) != 1 {
+		t.Fatalf("package chat = %+v", chat)
+	}
+	echo := chat.Services[0].Methods[0]
+	if len(echo.HTTP) != 1 || echo.HTTP[0].Path != "/v1/echo" || len(echo.Options) != 2 || echo.Options[0].Name != "(chat.audited)" {
+		t.Errorf("Echo = %+v", echo)
+	}
+
+	// No workspace is needed to call a server by reflection
+	result, err := app.InvokeRPC(InvokeRequest{Target: target, Method: "chat.Chat/Echo", Body: `{"text": "hi"}`, Reflection: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response map[string]string
+	if err := json.Unmarshal([]byte(result.Response), &response); err != nil || result.Code != "OK" || response["text"] != "hi" {
+		t.Errorf("Echo by reflection = %+v", result)
+	}
+	info, err := app.StartStream(InvokeRequest{Target: target, Method: "chat.Chat/Talk", Reflection: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Streaming != "bidi streaming" {
+		t.Errorf("StartStream by reflection = %+v", info)
+	}
+	app.CancelStream(info.ID)
+	if _, err := app.InvokeRPC(InvokeRequest{Target: target, Method: "chat.Chat/Echo"}); err == nil {
+		t.Error("invoked without a workspace or reflection")
+	}
+}
+
+// TestExportServerProtos writes the reflected protos into a workspace,
+// over the v1alpha protocol, and skips existing files and dependencies
+func TestExportServerProtos(t *testing.T) {
+	target := serveReflectedChat(t, false)
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	mine := "// mine\n" + reflectedChat["chat/options.proto"]
+	writeFiles(t, ws.PBPath(), map[string]string{"chat/options.proto": mine})
+
+	result, err := app.ExportServerProtos(target, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ExportResult{
+		Target:   target,
+		Protocol: "v1alpha",
+		Written:  []string{"chat/chat.proto"},
+		Skipped:  []string{"chat/options.proto", "google/api/annotations.proto", "google/api/http.proto", "google/protobuf/descriptor.proto", "grpc/reflection/v1alpha/reflection.proto"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("export = %+v, want %+v", result, want)
+	}
+	if content, _ := os.ReadFile(filepath.Join(ws.PBPath(), "chat", "options.proto")); string(content) != mine {
+		t.Errorf("existing file overwritten: %q", content)
+	}
+
+	// The existing file does not declare the option, so the export fails
+	// before writing anything
+	os.Remove(filepath.Join(ws.PBPath(), "chat", "chat.proto"))
+	writeFiles(t, ws.PBPath(), map[string]string{"chat/options.proto": "syntax = \"proto3\";\npackage chat;\n"})
+	if _, err := app.ExportServerProtos(target, false, false); err == nil {
+		t.Error("exported protos that do not compile")
+	}
+	if _, err := os.Stat(filepath.Join(ws.PBPath(), "chat", "chat.proto")); err == nil {
+		t.Error("chat.proto written by a failed export")
+	}
+
+	result, err = app.ExportServerProtos(target, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Written, []string{"chat/chat.proto", "chat/options.proto"}) {
+		t.Errorf("overwriting export wrote %v", result.Written)
+	}
+	for name, want := range reflectedChat {
+		if content, _ := os.ReadFile(filepath.Join(ws.PBPath(), filepath.FromSlash(name))); string(content) != want {
+			t.Errorf("%s =\n%s\nwant\n%s", name, content, want)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// WorkspaceSchema is the schema of every proto of a workspace, grouped by
+// package
+type WorkspaceSchema struct {
+	Workspace string          `json:"workspace"`
+	Packages  []SchemaPackage `json:"packages"`
+}
+
+// SchemaPackage is a proto package with the top-level types of its files
+type SchemaPackage struct {
+	Name     string          `json:"name"`
+	Files    []string        `json:"files"`
+	Services []SchemaService `json:"services"`
+	Messages []SchemaMessage `json:"messages"`
+	Enums    []SchemaEnum    `json:"enums"`
+}
+
+// SchemaElement locates a declaration and carries its doc comment: the
+// leading comment, or the trailing one when there is none. Line is
+// one-based, zero when unknown.
+type SchemaElement struct {
+	Name     string         `json:"name"`
+	FullName string         `json:"fullName"`
+	File     string         `json:"file"`
+	Line     int            `json:"line"`
+	Comment  string         `json:"comment,omitempty"`
+	Options  []SchemaOption `json:"options"`
+}
+
+// SchemaOption is an option set on a declaration. Custom options are named
+// in parentheses; Value is the option as JSON.
+type SchemaOption struct {
+	Name      string      `json:"name"`
+	Extension bool        `json:"extension"`
+	Value     interface{} `json:"value"`
+}
+
+// SchemaService is a service and its RPCs
+type SchemaService struct {
+	SchemaElement
+	Methods []SchemaMethod `json:"methods"`
+}
+
+// SchemaMethod is an RPC. Streaming is unary, client streaming, server
+// streaming or bidi streaming.
+type SchemaMethod struct {
+	SchemaElement
+	Input           string        `json:"input"`
+	Output          string        `json:"output"`
+	ClientStreaming bool          `json:"clientStreaming"`
+	ServerStreaming bool          `json:"serverStreaming"`
+	Streaming       string        `json:"streaming"`
+	HTTP            []HTTPBinding `json:"http"`
+}
+
+// HTTPBinding is a google.api.http binding of an RPC
+type HTTPBinding struct {
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	Body         string `json:"body,omitempty"`
+	ResponseBody string `json:"responseBody,omitempty"`
+}
+
+// SchemaMessage is a message with its fields and nested types
+type SchemaMessage struct {
+	SchemaElement
+	Fields   []SchemaField   `json:"fields"`
+	Messages []SchemaMessage `json:"messages"`
+	Enums    []SchemaEnum    `json:"enums"`
+}
+
+// SchemaField is a message field. Type is a scalar kind, the full name of
+// a message or enum, or map<key, value>; Label is repeated, optional,
+// required or empty.
+type SchemaField struct {
+	SchemaElement
+	Number   int32  `json:"number"`
+	Type     string `json:"type"`
+	Label    string `json:"label,omitempty"`
+	JSONName string `json:"jsonName"`
+	Oneof    string `json:"oneof,omitempty"`
+}
+
+// SchemaEnum is an enum with its values
+type SchemaEnum struct {
+	SchemaElement
+	Values []SchemaEnumValue `json:"values"`
+}
+
+// SchemaEnumValue is an enum value
+type SchemaEnumValue struct {
+	SchemaElement
+	Number int32 `json:"number"`
+}
+
+// typeFiles looks up the descriptors and types of a set of protos, such as
+// a compilation result or the files pulled from a server by reflection
+type typeFiles interface {
+	FindDescriptorByName(protoreflect.FullName) (protoreflect.Descriptor, error)
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// typeResolver finds messages and extensions among the protos first, so
+// the custom options and request types of the workspace are found, then
+// among the types linked into pb-tool. It implements
+// protoregistry.ExtensionTypeResolver and protoregistry.MessageTypeResolver.
+type typeResolver struct {
+	files typeFiles
+}
+
+// FindExtensionByName finds an extension by its full name
+func (r typeResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	if xt, err := r.files.FindExtensionByName(field); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+// FindExtensionByNumber finds an extension of message by its field number
+func (r typeResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	if xt, err := r.files.FindExtensionByNumber(message, field); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
+
+// FindMessageByName finds a message type by its full name
+func (r typeResolver) FindMessageByName(message protoreflect.FullName) (protoreflect.MessageType, error) {
+	if mt, err := r.files.FindMessageByName(message); err == nil {
+		return mt, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByName(message)
+}
+
+// FindMessageByURL finds a message type by its type URL, as in an Any
+func (r typeResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	if mt, err := r.files.FindMessageByURL(url); err == nil {
+		return mt, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByURL(url)
+}
+
+// schemaBuilder converts compiled descriptors to the schema tree
+type schemaBuilder struct {
+	resolver typeResolver
+}
+
+// describe builds the schema of files
+func (b *schemaBuilder) describe(files []protoreflect.FileDescriptor) []SchemaPackage {
+	packages := make(map[protoreflect.FullName]*SchemaPackage)
+	var names []protoreflect.FullName
+	for _, fd := range files {
+		pkg, ok := packages[fd.Package()]
+		if !ok {
+			pkg = &SchemaPackage{
+				Name:     string(fd.Package()),
+				Files:    []string{},
+				Services: []SchemaService{},
+				Messages: []SchemaMessage{},
+				Enums:    []SchemaEnum{},
+			}
+			packages[fd.Package()] = pkg
+			names = append(names, fd.Package())
+		}
+		pkg.Files = append(pkg.Files, fd.Path())
+		for i := 0; i < fd.Services().Len(); i++ {
+			pkg.Services = append(pkg.Services, b.service(fd.Services().Get(i)))
+		}
+		pkg.Messages = append(pkg.Messages, b.messages(fd.Messages())...)
+		pkg.Enums = append(pkg.Enums, b.enums(fd.Enums())...)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	result := make([]SchemaPackage, len(names))
+	for i, name := range names {
+		result[i] = *packages[name]
+	}
+	return result
+}
+
+// element describes the name, location, comment and options of d
+func (b *schemaBuilder) element(d protoreflect.Descriptor) SchemaElement {
+	e := SchemaElement{
+		Name:     string(d.Name()),
+		FullName: string(d.FullName()),
+		File:     d.ParentFile().Path(),
+		Options:  b.options(d.Options()),
+	}
+	loc := d.ParentFile().SourceLocations().ByDescriptor(d)
+	if loc.Path != nil {
+		e.Line = loc.StartLine + 1
+	}
+	e.Comment = strings.TrimSpace(loc.LeadingComments)
+	if e.Comment == "" {
+		e.Comment = strings.TrimSpace(loc.TrailingComments)
+	}
+	return e
+}
+
+// service describes a service and its RPCs
+func (b *schemaBuilder) service(sd protoreflect.ServiceDescriptor) SchemaService {
+	s := SchemaService{SchemaElement: b.element(sd), Methods: []SchemaMethod{}}
+	for i := 0; i < sd.Methods().Len(); i++ {
+		md := sd.Methods().Get(i)
+		m := SchemaMethod{
+			SchemaElement:   b.element(md),
+			Input:           string(md.Input().FullName()),
+			Output:          string(md.Output().FullName()),
+			ClientStreaming: md.IsStreamingClient(),
+			ServerStreaming: md.IsStreamingServer(),
+			Streaming:       streamingKind(md),
+			HTTP:            []HTTPBinding{},
+		}
+		if rule := httpRule(md); rule != nil {
+			for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				verb, path := httpPattern(r)
+				if verb == "" {
+					continue
+				}
+				m.HTTP = append(m.HTTP, HTTPBinding{Method: verb, Path: path, Body: r.GetBody(), ResponseBody: r.GetResponseBody()})
+			}
+		}
+		s.Methods = append(s.Methods, m)
+	}
+	return s
+}
+
+// messages describes messages and their nested types, skipping the
+// synthetic map entries
+func (b *schemaBuilder) messages(mds protoreflect.MessageDescriptors) []SchemaMessage {
+	messages := []SchemaMessage{}
+	for i := 0; i < mds.Len(); i++ {
+		md := mds.Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+		m := SchemaMessage{
+			SchemaElement: b.element(md),
+			Fields:        []SchemaField{},
+			Messages:      b.messages(md.Messages()),
+			Enums:         b.enums(md.Enums()),
+		}
+		for j := 0; j < md.Fields().Len(); j++ {
+			fd := md.Fields().Get(j)
+			f := SchemaField{
+				SchemaElement: b.element(fd),
+				Number:        int32(fd.Number()),
+				Type:          fieldTypeName(fd),
+				JSONName:      fd.JSONName(),
+				Oneof:         oneofName(fd),
+			}
+			switch {
+			case fd.IsMap():
+			case fd.IsList():
+				f.Label = "repeated"
+			case fd.Cardinality() == protoreflect.Required:
+				f.Label = "required"
+			case fd.HasOptionalKeyword():
+				f.Label = "optional"
+			}
+			m.Fields = append(m.Fields, f)
+		}
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+// enums describes enums and their values
+func (b *schemaBuilder) enums(eds protoreflect.EnumDescriptors) []SchemaEnum {
+	enums := []SchemaEnum{}
+	for i := 0; i < eds.Len(); i++ {
+		ed := eds.Get(i)
+		e := SchemaEnum{SchemaElement: b.element(ed), Values: []SchemaEnumValue{}}
+		for j := 0; j < ed.Values().Len(); j++ {
+			vd := ed.Values().Get(j)
+			e.Values = append(e.Values, SchemaEnumValue{SchemaElement: b.element(vd), Number: int32(vd.Number())})
+		}
+		enums = append(enums, e)
+	}
+	return enums
+}
+
+// options lists the options set in an options message, sorted by name
+func (b *schemaBuilder) options(opts proto.Message) []SchemaOption {
+	options := []SchemaOption{}
+	parsed := resolveOptions(opts, b.resolver)
+	if parsed == nil {
+		return options
+	}
+	parsed.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		option := SchemaOption{Name: string(fd.Name()), Extension: fd.IsExtension(), Value: b.optionValue(fd, v)}
+		if fd.IsExtension() {
+			option.Name = "(" + string(fd.FullName()) + ")"
+		}
+		options = append(options, option)
+		return true
+	})
+	sort.Slice(options, func(i, j int) bool { return options[i].Name < options[j].Name })
+	return options
+}
+
+// resolveOptions decodes an options message again with types, since custom
+// options may have been kept as unknown fields. It returns nil when opts is
+// unset or cannot be decoded.
+func resolveOptions(opts proto.Message, types typeResolver) protoreflect.Message {
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return nil
+	}
+	data, err := proto.Marshal(opts)
+	if err != nil {
+		return nil
+	}
+	parsed := opts.ProtoReflect().New().Interface()
+	if err := (proto.UnmarshalOptions{Resolver: types}).Unmarshal(data, parsed); err != nil {
+		return nil
+	}
+	return parsed.ProtoReflect()
+}
+
+// optionValue converts an option value to its JSON form
+func (b *schemaBuilder) optionValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		values := make([]interface{}, list.Len())
+		for i := range values {
+			values[i] = b.singularValue(fd, list.Get(i))
+		}
+		return values
+	case fd.IsMap():
+		values := make(map[string]interface{})
+		v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			values[k.String()] = b.singularValue(fd.MapValue(), mv)
+			return true
+		})
+		return values
+	}
+	return b.singularValue(fd, v)
+}
+
+// singularValue converts one option value: enums by name and messages as
+// their protojson encoding. Like protojson, 64-bit integers become decimal
+// strings, which JavaScript numbers cannot hold exactly, and non-finite
+// floats become "NaN", "Infinity" or "-Infinity", which JSON cannot hold.
+func (b *schemaBuilder) singularValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return strconv.FormatInt(v.Int(), 10)
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(v.Uint(), 10)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		switch f := v.Float(); {
+		case math.IsNaN(f):
+			return "NaN"
+		case math.IsInf(f, 1):
+			return "Infinity"
+		case math.IsInf(f, -1):
+			return "-Infinity"
+		}
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		data, err := protojson.MarshalOptions{Resolver: b.resolver}.Marshal(v.Message().Interface())
+		if err != nil {
+			return nil
+		}
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil
+		}
+		return value
+	}
+	return v.Interface()
+}
+
+// DescribeWorkspace compiles every proto of the workspace and returns its
+// schema: packages, services, methods, messages, fields and enums with
+// their comments and options
+func (a *App) DescribeWorkspace() (*WorkspaceSchema, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := ws.CompileAll(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	b := &schemaBuilder{resolver: typeResolver{files: compiled.AsResolver()}}
+	return &WorkspaceSchema{Workspace: ws.Root, Packages: b.describe(linkedDescriptors(compiled))}, nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestDescribeWorkspace checks the tree of a workspace with custom options,
+// HTTP bindings, streaming RPCs, nested types and comments
+func TestDescribeWorkspace(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{
+		"shop/options.proto": `syntax = "proto3";
+package shop.options;
+import "google/protobuf/descriptor.proto";
+
+message Audit {
+  string owner = 1;
+  repeated string tags = 2;
+}
+
+extend google.protobuf.MethodOptions {
+  bool publish = 10001;
+  Audit audit = 10002;
+}
+`,
+		"shop/order.proto": `syntax = "proto3";
+package shop;
+import "google/api/annotations.proto";
+import "shop/options.proto";
+
+// Order is a customer order
+message Order {
+  // the order id
+  string id = 1 [deprecated = true];
+  map<string, int32> counts = 2;
+  optional string note = 3;
+  oneof payment {
+    string card = 4;
+  }
+  repeated Item items = 5;
+
+  message Item {
+    string sku = 1; // stock keeping unit
+  }
+  enum State {
+    STATE_UNSPECIFIED = 0;
+  }
+}
+
+service OrderService {
+  // GetOrder returns an order
+  rpc GetOrder (Order) returns (Order) {
+    option (google.api.http) = {
+      get: "/v1/orders/{id}"
+      additional_bindings: { post: "/v1/orders:get" body: "*" }
+    };
+    option (shop.options.publish) = true;
+    option (shop.options.audit) = { owner: "team", tags: ["a", "b"] };
+  }
+  rpc Watch (Order) returns (stream Order);
+  rpc Upload (stream Order) returns (Order);
+  rpc Chat (stream Order) returns (stream Order);
+}
+`,
+	})
+
+	schema, err := app.DescribeWorkspace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Packages) != 2 || schema.Packages[0].Name != "shop" || schema.Packages[1].Name != "shop.options" {
+		t.Fatalf("packages = %+v", schema.Packages)
+	}
+	pkg := schema.Packages[0]
+	if !reflect.DeepEqual(pkg.Files, []string{"shop/order.proto"}) || len(pkg.Services) != 1 || len(pkg.Messages) != 1 {
+		t.Fatalf("package shop = %+v", pkg)
+	}
+
+	order := pkg.Messages[0]
+	if order.FullName != "shop.Order" || order.Comment != "Order is a customer order" || order.Line != 7 {
+		t.Errorf("message = %s line %d %q", order.FullName, order.Line, order.Comment)
+	}
+	var fields []string
+	for _, f := range order.Fields {
+		fields = append(fields, f.Label+" "+f.Type+" "+f.Name+" "+f.Oneof)
+	}
+	want := []string{
+		" string id ",
+		" map<string, int32> counts ",
+		"optional string note ",
+		" string card payment",
+		"repeated shop.Order.Item items ",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %q, want %q", fields, want)
+	}
+	id := order.Fields[0]
+	if id.Comment != "the order id" || !reflect.DeepEqual(id.Options, []SchemaOption{{Name: "deprecated", Value: true}}) {
+		t.Errorf("field id = %+v", id)
+	}
+	if len(order.Messages) != 1 || order.Messages[0].Fields[0].Comment != "stock keeping unit" {
+		t.Errorf("nested messages = %+v", order.Messages)
+	}
+	if len(order.Enums) != 1 || order.Enums[0].Values[0].FullName != "shop.Order.STATE_UNSPECIFIED" {
+		t.Errorf("nested enums = %+v", order.Enums)
+	}
+
+	var kinds []string
+	for _, m := range pkg.Services[0].Methods {
+		kinds = append(kinds, m.Name+" "+m.Streaming)
+	}
+	want = []string{"GetOrder unary", "Watch server streaming", "Upload client streaming", "Chat bidi streaming"}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("methods = %q, want %q", kinds, want)
+	}
+
+	get := pkg.Services[0].Methods[0]
+	if get.Input != "shop.Order" || get.Comment != "GetOrder returns an order" {
+		t.Errorf("GetOrder = %+v", get)
+	}
+	wantHTTP := []HTTPBinding{{Method: "GET", Path: "/v1/orders/{id}"}, {Method: "POST", Path: "/v1/orders:get", Body: "*"}}
+	if !reflect.DeepEqual(get.HTTP, wantHTTP) {
+		t.Errorf("http = %+v, want %+v", get.HTTP, wantHTTP)
+	}
+	var names []string
+	for _, o := range get.Options {
+		names = append(names, o.Name)
+	}
+	if want := []string{"(google.api.http)", "(shop.options.audit)", "(shop.options.publish)"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("options = %q, want %q", names, want)
+	}
+	audit := map[string]interface{}{"owner": "team", "tags": []interface{}{"a", "b"}}
+	if !reflect.DeepEqual(get.Options[1].Value, audit) || get.Options[2].Value != true || !get.Options[2].Extension {
+		t.Errorf("custom options = %+v", get.Options)
+	}
+}
+
+// TestDescribeWorkspace_ScalarOptions checks that 64-bit integer and
+// non-finite float options are encoded as strings, as protojson does
+func TestDescribeWorkspace_ScalarOptions(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{
+		"limits.proto": `syntax = "proto3";
+package limits;
+import "google/protobuf/descriptor.proto";
+
+extend google.protobuf.MessageOptions {
+  int64 max = 10001;
+  uint64 umax = 10002;
+  sfixed64 fixed_min = 10003;
+  double ratio = 10004;
+  float scale = 10005;
+  repeated double bounds = 10006;
+}
+
+message Int64 { option (max) = 9007199254740993; }
+message Uint64 { option (umax) = 18446744073709551615; }
+message Sfixed64 { option (fixed_min) = -9223372036854775808; }
+message NaN { option (ratio) = nan; }
+message Infinity { option (scale) = inf; }
+message Bounds { option (bounds) = -inf; option (bounds) = 1.5; }
+`,
+	})
+
+	schema, err := app.DescribeWorkspace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := json.Marshal(schema); err != nil {
+		t.Fatalf("schema does not encode as JSON: %v", err)
+	}
+
+	tests := []struct {
+		message string
+		want    interface{}
+	}{
+		{"Int64", "9007199254740993"},
+		{"Uint64", "18446744073709551615"},
+		{"Sfixed64", "-9223372036854775808"},
+		{"NaN", "NaN"},
+		{"Infinity", "Infinity"},
+		{"Bounds", []interface{}{"-Infinity", 1.5}},
+	}
+	for _, tt := range tests {
+		var found *SchemaMessage
+		for i := range schema.Packages[0].Messages {
+			if m := &schema.Packages[0].Messages[i]; m.Name == tt.message {
+				found = m
+			}
+		}
+		if found == nil || len(found.Options) != 1 {
+			t.Errorf("%s: options = %+v", tt.message, found)
+			continue
+		}
+		if got := found.Options[0].Value; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: value = %#v, want %#v", tt.message, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// RPC stream events sent to the frontend
+const (
+	// EventRPCHeaders carries the StreamHeaders of a stream once the server
+	// sends them
+	EventRPCHeaders = "rpc:headers"
+	// EventRPCMessage carries a StreamMessage for every response received
+	EventRPCMessage = "rpc:message"
+	// EventRPCDone carries the StreamDone of a finished stream
+	EventRPCDone = "rpc:done"
+)
+
+// StreamInfo describes an open stream. Streaming is unary, client
+// streaming, server streaming or bidi streaming.
+type StreamInfo struct {
+	ID        string `json:"id"`
+	Method    string `json:"method"`
+	Streaming string `json:"streaming"`
+}
+
+// StreamHeaders is the header metadata of a stream
+type StreamHeaders struct {
+	StreamID string              `json:"streamId"`
+	Headers  map[string][]string `json:"headers"`
+}
+
+// StreamMessage is a response received on a stream. Index counts the
+// responses of the stream from zero.
+type StreamMessage struct {
+	StreamID string `json:"streamId"`
+	Index    int    `json:"index"`
+	Response string `json:"response"`
+}
+
+// StreamDone is the outcome of a stream. Code is the gRPC status code
+// name; Canceled when the stream was canceled.
+type StreamDone struct {
+	StreamID  string              `json:"streamId"`
+	Code      string              `json:"code"`
+	Message   string              `json:"message,omitempty"`
+	Trailers  map[string][]string `json:"trailers"`
+	Sent      int                 `json:"sent"`
+	Received  int                 `json:"received"`
+	LatencyMs int64               `json:"latencyMs"`
+}
+
+// rpcStream is an RPC started with StartStream. Requests are sent by the
+// frontend while a goroutine receives the responses.
+type rpcStream struct {
+	ID     string
+	method protoreflect.MethodDescriptor
+	types  typeResolver
+	conn   *grpc.ClientConn
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+	emit   func(name string, data interface{})
+	start  time.Time
+	done   chan struct{}
+
+	mu         sync.Mutex
+	sent       int
+	halfClosed bool
+}
+
+// openStream starts the RPC of req. The request body is sent as the first
+// message, unless it is empty on a client streaming RPC; RPCs that take a
+// single request are half-closed at once. The caller starts receiving the
+// responses with receive once it can route the events of the stream.
+func openStream(types typeResolver, req InvokeRequest, id string, emit func(string, interface{})) (*rpcStream, error) {
+	method, err := findMethod(types, req.Method)
+	if err != nil {
+		return nil, err
+	}
+	var first *dynamicpb.Message
+	if !method.IsStreamingClient() || strings.TrimSpace(req.Body) != "" {
+		if first, err = newMessage(method.Input(), req.Body, types); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := dial(req.Target, req.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := outgoingContext(context.Background(), req, 0)
+	desc := &grpc.StreamDesc{
+		StreamName:    string(method.Name()),
+		ClientStreams: method.IsStreamingClient(),
+		ServerStreams: method.IsStreamingServer(),
+	}
+	s := &rpcStream{
+		ID:     id,
+		method: method,
+		types:  types,
+		conn:   conn,
+		cancel: cancel,
+		emit:   emit,
+		start:  time.Now(),
+		done:   make(chan struct{}),
+	}
+	if s.stream, err = conn.NewStream(ctx, desc, fullMethodName(method)); err != nil {
+		cancel()
+		conn.Close()
+		return nil, err
+	}
+
+	if first != nil {
+		// A failed send is reported by the receiver with the RPC status
+		if s.stream.SendMsg(first) == nil {
+			s.sent++
+		}
+	}
+	if !method.IsStreamingClient() {
+		s.halfClosed = true
+		s.stream.CloseSend()
+	}
+	return s, nil
+}
+
+// info describes the stream
+func (s *rpcStream) info() StreamInfo {
+	return StreamInfo{ID: s.ID, Method: fullMethodName(s.method), Streaming: streamingKind(s.method)}
+}
+
+// receive reads the responses until the RPC ends and reports them
+func (s *rpcStream) receive() {
+	defer close(s.done)
+	defer s.conn.Close()
+	defer s.cancel()
+
+	if headers, err := s.stream.Header(); err == nil {
+		s.emit(EventRPCHeaders, StreamHeaders{StreamID: s.ID, Headers: metadataMap(headers)})
+	}
+
+	received := 0
+	var err error
+	for {
+		out := dynamicpb.NewMessage(s.method.Output())
+		if err = s.stream.RecvMsg(out); err != nil {
+			break
+		}
+		s.emit(EventRPCMessage, StreamMessage{StreamID: s.ID, Index: received, Response: messageJSON(out, s.types)})
+		received++
+		if !s.method.IsStreamingServer() {
+			break
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+
+	st := status.Convert(err)
+	s.mu.Lock()
+	sent := s.sent
+	s.mu.Unlock()
+	s.emit(EventRPCDone, StreamDone{
+		StreamID:  s.ID,
+		Code:      st.Code().String(),
+		Message:   st.Message(),
+		Trailers:  metadataMap(s.stream.Trailer()),
+		Sent:      sent,
+		Received:  received,
+		LatencyMs: time.Since(s.start).Milliseconds(),
+	})
+}
+
+// send sends a request on a client or bidi streaming RPC
+func (s *rpcStream) send(body string) error {
+	if !s.method.IsStreamingClient() {
+		return fmt.Errorf("%s is a %s RPC and takes a single request", s.method.FullName(), streamingKind(s.method))
+	}
+	msg, err := newMessage(s.method.Input(), body, s.types)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.halfClosed {
+		return errors.New("the stream is closed for sending")
+	}
+	if err := s.stream.SendMsg(msg); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("the stream has finished")
+		}
+		return err
+	}
+	s.sent++
+	return nil
+}
+
+// closeSend half-closes the stream: the server sees the end of the
+// requests while responses are still received
+func (s *rpcStream) closeSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.halfClosed {
+		return nil
+	}
+	s.halfClosed = true
+	return s.stream.CloseSend()
+}
+
+// stream returns the open stream with the given ID
+func (a *App) stream(id string) (*rpcStream, error) {
+	a.streamsMu.Lock()
+	defer a.streamsMu.Unlock()
+	s, ok := a.streams[id]
+	if !ok {
+		return nil, fmt.Errorf("no open stream %s", id)
+	}
+	return s, nil
+}
+
+// addStream opens the stream of req and tracks it until it finishes. The
+// stream is tracked before its responses are received, so every event it
+// sends names a stream the app knows.
+func (a *App) addStream(types typeResolver, req InvokeRequest) (*rpcStream, error) {
+	s, err := openStream(types, req, a.generateID(), a.emit)
+	if err != nil {
+		return nil, err
+	}
+	a.streamsMu.Lock()
+	a.streams[s.ID] = s
+	a.streamsMu.Unlock()
+
+	go func() {
+		s.receive()
+		a.streamsMu.Lock()
+		delete(a.streams, s.ID)
+		a.streamsMu.Unlock()
+	}()
+	return s, nil
+}
+
+// cancelStreams cancels every open stream
+func (a *App) cancelStreams() {
+	a.streamsMu.Lock()
+	defer a.streamsMu.Unlock()
+	for _, s := range a.streams {
+		s.cancel()
+	}
+}
+
+// StartStream starts an RPC of any streaming mode on a running server and
+// returns at once. Responses are reported with rpc:message events and the
+// outcome with an rpc:done event; client and bidi streaming RPCs take
+// more requests with SendStreamMessage until CloseStreamSend.
+func (a *App) StartStream(req InvokeRequest) (StreamInfo, error) {
+	types, err := a.requestTypes(context.Background(), req)
+	if err != nil {
+		return StreamInfo{}, err
+	}
+	s, err := a.addStream(types, req)
+	if err != nil {
+		return StreamInfo{}, err
+	}
+	return s.info(), nil
+}
+
+// SendStreamMessage sends a JSON request on an open client or bidi
+// streaming RPC
+func (a *App) SendStreamMessage(id, body string) error {
+	s, err := a.stream(id)
+	if err != nil {
+		return err
+	}
+	return s.send(body)
+}
+
+// CloseStreamSend half-closes an open stream, telling the server no more
+// requests follow
+func (a *App) CloseStreamSend(id string) error {
+	s, err := a.stream(id)
+	if err != nil {
+		return err
+	}
+	return s.closeSend()
+}
+
+// CancelStream cancels an open stream. Its rpc:done event reports the
+// Canceled status.
+func (a *App) CancelStream(id string) error {
+	s, err := a.stream(id)
+	if err != nil {
+		return err
+	}
+	s.cancel()
+	return nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// chatProto has an RPC of every streaming mode
+const chatProto = `syntax = "proto3";
+package chat;
+
+message Msg {
+  string text = 1;
+}
+
+service Chat {
+  rpc Echo (Msg) returns (Msg);
+  rpc Repeat (Msg) returns (stream Msg);
+  rpc Join (stream Msg) returns (Msg);
+  rpc Talk (stream Msg) returns (stream Msg);
+}
+`
+
+// serveChat serves chat.Chat with dynamic messages: Echo and Talk echo
+// their requests, Repeat sends its request three times and Join joins the
+// texts of its requests. setup, when set, registers more services.
+func serveChat(t *testing.T, md protoreflect.MessageDescriptor, setup func(*grpc.Server)) string {
+	t.Helper()
+	text := md.Fields().ByName("text")
+	newMsg := func(s string) *dynamicpb.Message {
+		m := dynamicpb.NewMessage(md)
+		m.Set(text, protoreflect.ValueOfString(s))
+		return m
+	}
+	recv := func(stream grpc.ServerStream) (string, error) {
+		m := dynamicpb.NewMessage(md)
+		if err := stream.RecvMsg(m); err != nil {
+			return "", err
+		}
+		return m.Get(text).String(), nil
+	}
+
+	echo := func(_ interface{}, stream grpc.ServerStream) error {
+		stream.SetHeader(metadata.Pairs("x-chat", "hello"))
+		for {
+			s, err := recv(stream)
+			if err == io.EOF {
+				stream.SetTrailer(metadata.Pairs("x-done", "yes"))
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := stream.SendMsg(newMsg(s)); err != nil {
+				return err
+			}
+		}
+	}
+	repeat := func(_ interface{}, stream grpc.ServerStream) error {
+		s, err := recv(stream)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < 3; i++ {
+			if err := stream.SendMsg(newMsg(s)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	join := func(_ interface{}, stream grpc.ServerStream) error {
+		var texts []string
+		for {
+			s, err := recv(stream)
+			if err == io.EOF {
+				return stream.SendMsg(newMsg(strings.Join(texts, " ")))
+			}
+			if err != nil {
+				return err
+			}
+			texts = append(texts, s)
+		}
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "chat.Chat",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{
+			{StreamName: "Echo", Handler: echo},
+			{StreamName: "Repeat", Handler: repeat, ServerStreams: true},
+			{StreamName: "Join", Handler: join, ClientStreams: true},
+			{StreamName: "Talk", Handler: echo, ClientStreams: true, ServerStreams: true},
+		},
+	}, struct{}{})
+	if setup != nil {
+		setup(s)
+	}
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+// streamRecorder collects the events of streams
+type streamRecorder struct {
+	mu       sync.Mutex
+	headers  []StreamHeaders
+	messages []string
+	done     []StreamDone
+}
+
+func (r *streamRecorder) emit(name string, data interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch e := data.(type) {
+	case StreamHeaders:
+		r.headers = append(r.headers, e)
+	case StreamMessage:
+		var m struct{ Text string }
+		json.Unmarshal([]byte(e.Response), &m)
+		r.messages = append(r.messages, m.Text)
+	case StreamDone:
+		r.done = append(r.done, e)
+	}
+}
+
+// wait waits for s to finish and returns the texts it received and its
+// outcome
+func (r *streamRecorder) wait(t *testing.T, s *rpcStream) ([]string, StreamDone) {
+	t.Helper()
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stream %s did not finish", s.info().Method)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	messages, done := r.messages, r.done[len(r.done)-1]
+	r.messages = nil
+	return messages, done
+}
+
+// TestStreams calls an RPC of every streaming mode and checks sending,
+// half-closing and canceling
+func TestStreams(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{"chat.proto": chatProto})
+	types, err := app.workspaceTypes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	md, err := types.FindMessageByName("chat.Msg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := serveChat(t, md.Descriptor(), nil)
+
+	rec := &streamRecorder{}
+	open := func(method, body string) *rpcStream {
+		t.Helper()
+		s, err := openStream(types, InvokeRequest{Target: target, Method: method, Body: body}, method, rec.emit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go s.receive()
+		return s
+	}
+
+	s := open("chat.Chat.Echo", `{"text": "hi"}`)
+	if s.info().Streaming != "unary" || s.send(`{}`) == nil {
+		t.Errorf("unary stream %+v accepted a second request", s.info())
+	}
+	if messages, done := rec.wait(t, s); len(messages) != 1 || messages[0] != "hi" || done.Code != "OK" || done.Trailers["x-done"][0] != "yes" {
+		t.Errorf("Echo = %q, %+v", messages, done)
+	}
+
+	s = open("chat.Chat.Repeat", `{"text": "again"}`)
+	if messages, done := rec.wait(t, s); len(messages) != 3 || done.Received != 3 || done.Code != "OK" {
+		t.Errorf("Repeat = %q, %+v", messages, done)
+	}
+
+	s = open("chat.Chat.Join", "")
+	for _, text := range []string{"a", "b", "c"} {
+		if err := s.send(`{"text": "` + text + `"}`); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.closeSend(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.send(`{"text": "late"}`); err == nil {
+		t.Error("sent after half-close")
+	}
+	if messages, done := rec.wait(t, s); len(messages) != 1 || messages[0] != "a b c" || done.Sent != 3 {
+		t.Errorf("Join = %q, %+v", messages, done)
+	}
+
+	s = open("chat.Chat.Talk", `{"text": "one"}`)
+	if err := s.send(`{"text": "two"}`); err != nil {
+		t.Fatal(err)
+	}
+	s.closeSend()
+	if messages, done := rec.wait(t, s); len(messages) != 2 || messages[1] != "two" || done.Sent != 2 {
+		t.Errorf("Talk = %q, %+v", messages, done)
+	}
+	if len(rec.headers) == 0 || rec.headers[0].Headers["x-chat"][0] != "hello" {
+		t.Errorf("headers = %+v", rec.headers)
+	}
+
+	// Canceling ends an open stream with the Canceled status
+	s = open("chat.Chat.Talk", `{"text": "x"}`)
+	s.cancel()
+	if _, done := rec.wait(t, s); done.Code != "Canceled" {
+		t.Errorf("canceled Talk = %+v", done)
+	}
+
+	info, err := app.StartStream(InvokeRequest{Target: target, Method: "chat.Chat/Talk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Method != "/chat.Chat/Talk" || info.Streaming != "bidi streaming" {
+		t.Errorf("StartStream = %+v", info)
+	}
+	if err := app.SendStreamMessage(info.ID, `{"text": "x"}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.CancelStream(info.ID); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := app.stream(info.ID); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("canceled stream still open")
+		}
+	}
+	if err := app.CloseStreamSend(info.ID); err == nil {
+		t.Error("half-closed a finished stream")
+	}
+
+	// Streams that finish at once are still tracked first and then dropped
+	for i := 0; i < 20; i++ {
+		if _, err := app.StartStream(InvokeRequest{Target: target, Method: "chat.Chat.Echo", Body: `{"text": "fast"}`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		app.streamsMu.Lock()
+		tracked := len(app.streams)
+		app.streamsMu.Unlock()
+		if tracked == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d finished streams still tracked", tracked)
+		}
+	}
+
+	if _, err := app.StartStream(InvokeRequest{Target: target, Method: "chat.Chat.Echo", Body: "{"}); err == nil {
+		t.Error("stream started with invalid JSON")
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// PBTemplate is a built-in skeleton for new proto files
+type PBTemplate struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// pbTemplate is a PBTemplate with its source
+type pbTemplate struct {
+	PBTemplate
+	source *template.Template
+}
+
+// pbTemplateData fills in a template. Name is the PascalCase base name of
+// the new file, Resource its snake_case form.
+type pbTemplateData struct {
+	Package   string
+	GoPackage string
+	Name      string
+	Resource  string
+}
+
+// pbTemplates are the built-in templates in the order they are offered
+var pbTemplates = []pbTemplate{
+	{
+		PBTemplate: PBTemplate{
+			Name:        "crud",
+			Title:       "CRUD service",
+			Description: "A resource message with Get, List, Create, Update and Delete RPCs mapped to HTTP routes",
+		},
+		source: template.Must(template.New("crud").Parse(crudTemplate)),
+	},
+	{
+		PBTemplate: PBTemplate{
+			Name:        "streaming",
+			Title:       "Streaming service",
+			Description: "One RPC of each kind: unary, server streaming, client streaming and bidirectional streaming",
+		},
+		source: template.Must(template.New("streaming").Parse(streamingTemplate)),
+	},
+	{
+		PBTemplate: PBTemplate{
+			Name:        "options",
+			Title:       "Options extension",
+			Description: "Custom file, message, field and method options, like custom_options.proto",
+		},
+		source: template.Must(template.New("options").Parse(optionsTemplate)),
+	},
+}
+
+const crudTemplate = `syntax = "proto3";
+
+package {{.Package}};
+
+option go_package = "{{.GoPackage}}";
+
+import "google/api/annotations.proto";
+
+// {{.Name}} is the resource managed by {{.Name}}Service
+message {{.Name}} {
+  string id = 1;
+  string name = 2;
+}
+
+// {{.Name}}Service manages {{.Name}} resources
+service {{.Name}}Service {
+  // Get{{.Name}} returns a {{.Name}} by id
+  rpc Get{{.Name}} (Get{{.Name}}Request) returns (Get{{.Name}}Response) {
+    option (google.api.http) = {
+      get: "/v1/{{.Resource}}s/{id}"
+    };
+  }
+
+  // List{{.Name}}s returns a page of {{.Name}} resources
+  rpc List{{.Name}}s (List{{.Name}}sRequest) returns (List{{.Name}}sResponse) {
+    option (google.api.http) = {
+      get: "/v1/{{.Resource}}s"
+    };
+  }
+
+  // Create{{.Name}} creates a {{.Name}}
+  rpc Create{{.Name}} (Create{{.Name}}Request) returns (Create{{.Name}}Response) {
+    option (google.api.http) = {
+      post: "/v1/{{.Resource}}s"
+      body: "{{.Resource}}"
+    };
+  }
+
+  // Update{{.Name}} replaces a {{.Name}}
+  rpc Update{{.Name}} (Update{{.Name}}Request) returns (Update{{.Name}}Response) {
+    option (google.api.http) = {
+      put: "/v1/{{.Resource}}s/{ {{- .Resource}}.id}"
+      body: "{{.Resource}}"
+    };
+  }
+
+  // Delete{{.Name}} deletes a {{.Name}} by id
+  rpc Delete{{.Name}} (Delete{{.Name}}Request) returns (Delete{{.Name}}Response) {
+    option (google.api.http) = {
+      delete: "/v1/{{.Resource}}s/{id}"
+    };
+  }
+}
+
+message Get{{.Name}}Request {
+  string id = 1;
+}
+
+message Get{{.Name}}Response {
+  {{.Name}} {{.Resource}} = 1;
+}
+
+message List{{.Name}}sRequest {
+  int32 page_size = 1;
+  string page_token = 2;
+}
+
+message List{{.Name}}sResponse {
+  repeated {{.Name}} {{.Resource}}s = 1;
+  string next_page_token = 2;
+}
+
+message Create{{.Name}}Request {
+  {{.Name}} {{.Resource}} = 1;
+}
+
+message Create{{.Name}}Response {
+  {{.Name}} {{.Resource}} = 1;
+}
+
+message Update{{.Name}}Request {
+  {{.Name}} {{.Resource}} = 1;
+}
+
+message Update{{.Name}}Response {
+  {{.Name}} {{.Resource}} = 1;
+}
+
+message Delete{{.Name}}Request {
+  string id = 1;
+}
+
+message Delete{{.Name}}Response {}
+`
+
+const streamingTemplate = `syntax = "proto3";
+
+package {{.Package}};
+
+option go_package = "{{.GoPackage}}";
+
+// {{.Name}}Service shows the four kinds of gRPC methods
+service {{.Name}}Service {
+  // Unary sends one request and receives one response
+  rpc Unary ({{.Name}}Request) returns ({{.Name}}Response);
+
+  // ServerStream sends one request and receives a stream of responses
+  rpc ServerStream ({{.Name}}Request) returns (stream {{.Name}}Response);
+
+  // ClientStream sends a stream of requests and receives one response
+  rpc ClientStream (stream {{.Name}}Request) returns ({{.Name}}Response);
+
+  // BidiStream sends and receives streams of messages independently
+  rpc BidiStream (stream {{.Name}}Request) returns (stream {{.Name}}Response);
+}
+
+message {{.Name}}Request {
+  string message = 1;
+}
+
+message {{.Name}}Response {
+  string message = 1;
+  int64 sequence = 2;
+}
+`
+
+const optionsTemplate = `syntax = "proto3";
+
+package {{.Package}};
+
+option go_package = "{{.GoPackage}}";
+
+import "google/protobuf/descriptor.proto";
+
+// Custom file options
+extend google.protobuf.FileOptions {
+  // Owner of the proto file
+  string {{.Resource}}_owner = 50001;
+}
+
+// Custom message options
+extend google.protobuf.MessageOptions {
+  // Marks messages stored in a database table
+  string {{.Resource}}_table = 50001;
+}
+
+// Custom field options
+extend google.protobuf.FieldOptions {
+  // Marks fields holding sensitive data
+  bool {{.Resource}}_sensitive = 50001;
+}
+
+// Custom method options
+extend google.protobuf.MethodOptions {
+  // Marks methods other internal services may call
+  bool {{.Resource}}_publish = 50001;
+}
+`
+
+// ListPBTemplates returns the built-in templates CreatePBFromTemplate accepts
+func (a *App) ListPBTemplates() []PBTemplate {
+	templates := make([]PBTemplate, 0, len(pbTemplates))
+	for _, t := range pbTemplates {
+		templates = append(templates, t.PBTemplate)
+	}
+	return templates
+}
+
+// CreatePBFromTemplate creates the proto at filename, a slash-separated path
+// relative to the workspace pb directory, from the named built-in template
+// and returns its content. The package and go_package follow the other
+// protos of the directory. An existing file is never overwritten.
+func (a *App) CreatePBFromTemplate(filename, name string) (string, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return "", err
+	}
+	return ws.createFromTemplate(filename, name)
+}
+
+// createFromTemplate implements CreatePBFromTemplate
+func (w *Workspace) createFromTemplate(filename, name string) (string, error) {
+	var tmpl *pbTemplate
+	for i := range pbTemplates {
+		if pbTemplates[i].Name == name {
+			tmpl = &pbTemplates[i]
+		}
+	}
+	if tmpl == nil {
+		return "", fmt.Errorf("unknown proto template %q", name)
+	}
+
+	filePath, err := w.resolveProtoFile(filename)
+	if err != nil {
+		return "", err
+	}
+	filename = cleanProtoPath(filename)
+	if _, err := os.Stat(filePath); err == nil {
+		return "", fmt.Errorf("%s already exists", filename)
+	}
+
+	base := strings.TrimSuffix(path.Base(filename), ".proto")
+	data := pbTemplateData{Name: pascalCase(protoIdentifier(base)), Resource: protoIdentifier(base)}
+	if data.Resource == "" {
+		return "", fmt.Errorf("cannot derive a proto name from %s", filename)
+	}
+	data.Package, data.GoPackage = w.protoDefaults(filename)
+
+	var buf bytes.Buffer
+	if err := tmpl.source.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", err
+	}
+	// O_EXCL keeps a file created since the check above
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return "", err
+	}
+	return buf.String(), f.Close()
+}
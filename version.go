@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// maxKnownVersions bounds the contents remembered per proto for three-way diffs
+const maxKnownVersions = 16
+
+// PBFile is a proto read for editing. Version identifies the content on
+// disk and is passed back to SavePB.
+type PBFile struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	Version string `json:"version"`
+}
+
+// SaveResult is the outcome of SavePB. When the file changed on disk since
+// the version the caller read, nothing is written and Conflict describes
+// the change. Formatted is set when format on save changed the content,
+// which is then returned in Content.
+type SaveResult struct {
+	Saved     bool          `json:"saved"`
+	Path      string        `json:"path"`
+	Size      int64         `json:"size"`
+	Version   string        `json:"version"`
+	Message   string        `json:"message"`
+	Formatted bool          `json:"formatted,omitempty"`
+	Content   string        `json:"content,omitempty"`
+	Conflict  *SaveConflict `json:"conflict,omitempty"`
+}
+
+// SaveConflict describes a file that changed on disk since it was read.
+// Disk is the current content and DiskVersion its version, empty when the
+// file was deleted. Diff compares the content being saved and the disk
+// content with the version they share, when it is still known.
+type SaveConflict struct {
+	Path        string        `json:"path"`
+	BaseVersion string        `json:"baseVersion"`
+	DiskVersion string        `json:"diskVersion"`
+	Disk        string        `json:"disk"`
+	Diff        *ThreeWayDiff `json:"diff,omitempty"`
+}
+
+// versionToken identifies file content by modification time and hash. The
+// hash decides whether content changed, so a touched but unchanged file
+// still accepts saves; the time tells callers when it was last written.
+func versionToken(info os.FileInfo, content []byte) string {
+	return fmt.Sprintf("%d:%s", info.ModTime().UnixNano(), contentHash(content))
+}
+
+// versionHash returns the content hash of a version token
+func versionHash(version string) string {
+	if i := strings.IndexByte(version, ':'); i >= 0 {
+		return version[i+1:]
+	}
+	return version
+}
+
+// readVersion reads a file with its version token
+func readVersion(path string) ([]byte, string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, versionToken(info, content), nil
+}
+
+// versionStore remembers the content of the versions handed out for each
+// proto, so a conflicting save can be diffed against the version it was
+// based on
+type versionStore struct {
+	mu       sync.Mutex
+	contents map[string]map[string]string
+	order    map[string][]string
+}
+
+// newVersionStore returns an empty store
+func newVersionStore() *versionStore {
+	return &versionStore{
+		contents: make(map[string]map[string]string),
+		order:    make(map[string][]string),
+	}
+}
+
+// remember records content for the version of the file at path
+func (s *versionStore) remember(path, version string, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash := versionHash(version)
+	if s.contents[path] == nil {
+		s.contents[path] = make(map[string]string)
+	}
+	if _, ok := s.contents[path][hash]; ok {
+		return
+	}
+	s.contents[path][hash] = string(content)
+	s.order[path] = append(s.order[path], hash)
+	if len(s.order[path]) > maxKnownVersions {
+		delete(s.contents[path], s.order[path][0])
+		s.order[path] = s.order[path][1:]
+	}
+}
+
+// content returns the remembered content of a version of the file at path
+func (s *versionStore) content(path, version string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, ok := s.contents[path][versionHash(version)]
+	return content, ok
+}
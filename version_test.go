@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSavePB_Conflict checks that saves based on a stale version are
+// rejected with a three-way diff and that current versions are accepted
+func TestSavePB_Conflict(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "syntax = \"proto3\";\npackage demo;\nmessage A {}\n\nmessage B {}\n"
+	writeFiles(t, ws.PBPath(), map[string]string{"demo.proto": base})
+
+	read, err := app.ReadPB("demo.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Content != base || read.Version == "" {
+		t.Fatalf("ReadPB = %+v", read)
+	}
+
+	// Another tool edits the file
+	disk := "syntax = \"proto3\";\npackage demo;\nmessage A {}\n\nmessage B { string id = 1; }\n"
+	if err := os.WriteFile(filepath.Join(ws.PBPath(), "demo.proto"), []byte(disk), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mine := "syntax = \"proto3\";\npackage demo;\nmessage A { string name = 1; }\n\nmessage B {}\n"
+	result, err := app.SavePB("demo.proto", mine, read.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Saved || result.Conflict == nil {
+		t.Fatalf("stale save = %+v, want a conflict", result)
+	}
+	if result.Conflict.Disk != disk || result.Conflict.Diff == nil {
+		t.Fatalf("conflict = %+v, want the disk content and a diff", result.Conflict)
+	}
+	want := "syntax = \"proto3\";\npackage demo;\nmessage A { string name = 1; }\n\nmessage B { string id = 1; }\n"
+	if diff := result.Conflict.Diff; diff.Conflicts != 0 || diff.Merged != want {
+		t.Errorf("merged = %q with %d conflicts, want %q", diff.Merged, diff.Conflicts, want)
+	}
+	if content, _ := os.ReadFile(filepath.Join(ws.PBPath(), "demo.proto")); string(content) != disk {
+		t.Error("stale save overwrote the file")
+	}
+
+	// Saving on top of the disk version succeeds and returns the next version
+	saved, err := app.SavePB("demo.proto", result.Conflict.Diff.Merged, result.Conflict.DiskVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !saved.Saved || saved.Version == result.Conflict.DiskVersion {
+		t.Fatalf("save = %+v, want a new version", saved)
+	}
+	if again, err := app.SavePB("demo.proto", want, saved.Version); err != nil || !again.Saved {
+		t.Errorf("save with the returned version = %+v, %v", again, err)
+	}
+
+	// Without a version the save is unconditional
+	if forced, err := app.SavePB("demo.proto", base, ""); err != nil || !forced.Saved {
+		t.Errorf("unconditional save = %+v, %v", forced, err)
+	}
+}
+
+// TestDiff3 checks the merge of non-overlapping, identical and clashing changes
+func TestDiff3(t *testing.T) {
+	tests := []struct {
+		name, base, mine, theirs, merged string
+		conflicts                        int
+	}{
+		{"unchanged", "a\nb\n", "a\nb\n", "a\nb\n", "a\nb\n", 0},
+		{"mine only", "a\nb\n", "a\nB\n", "a\nb\n", "a\nB\n", 0},
+		{"theirs only", "a\nb\n", "a\nb\n", "a\nb\nc\n", "a\nb\nc\n", 0},
+		{"both apart", "a\nb\nc\n", "A\nb\nc\n", "a\nb\nC\n", "A\nb\nC\n", 0},
+		{"same change", "a\nb\n", "a\nX\n", "a\nX\n", "a\nX\n", 0},
+		{"clash", "a\nb\nc\n", "a\nmine\nc\n", "a\ntheirs\nc\n", "a\n<<<<<<< editor\nmine\n||||||| base\nb\n=======\ntheirs\n>>>>>>> disk\nc\n", 1},
+		{"deleted on disk", "a\nb\n", "a\nb\nc\n", "", "<<<<<<< editor\na\nb\nc\n||||||| base\na\nb\n=======\n>>>>>>> disk\n", 1},
+	}
+	for _, tt := range tests {
+		diff := diff3(tt.base, tt.mine, tt.theirs)
+		if diff.Merged != tt.merged || diff.Conflicts != tt.conflicts {
+			t.Errorf("%s: merged %q with %d conflicts, want %q with %d", tt.name, diff.Merged, diff.Conflicts, tt.merged, tt.conflicts)
+		}
+	}
+}
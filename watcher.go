@@ -0,0 +1,442 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// EventPBChanged carries a ProtoFileEvent whenever a watched file changes on disk
+const EventPBChanged = "pb:changed"
+
+// File change operations reported in ProtoFileEvent
+const (
+	FileCreated  = "created"
+	FileModified = "modified"
+	FileDeleted  = "deleted"
+)
+
+// watchDebounce groups the bursts of events editors and git produce for
+// one change
+const watchDebounce = 150 * time.Millisecond
+
+// gatewayConfigFile is the gRPC Gateway configuration watched next to the protos
+const gatewayConfigFile = "gateway.yaml"
+
+// ProtoFileEvent reports a change made outside the app to a proto or to
+// gateway.yaml. Path is relative to the pb directory for its protos, to the
+// workspace root otherwise. Conflict is set when the file is open in the
+// editor with unsaved changes that saving would overwrite.
+type ProtoFileEvent struct {
+	Workspace string `json:"workspace"`
+	Path      string `json:"path"`
+	Op        string `json:"op"`
+	Gateway   bool   `json:"gateway"`
+	Conflict  bool   `json:"conflict"`
+}
+
+// editorState is the proto open in the frontend editor. Base is the hash
+// of the content it was loaded from or last saved as.
+type editorState struct {
+	Workspace string
+	File      string
+	Base      string
+	Dirty     bool
+}
+
+// protoWatcher watches the proto roots and gateway.yaml of a workspace
+type protoWatcher struct {
+	ws      *Workspace
+	watcher *fsnotify.Watcher
+	// changed is called with each debounced change
+	changed func(ProtoFileEvent)
+
+	mu      sync.Mutex
+	pending map[string]fsnotify.Op
+	timer   *time.Timer
+	// protos holds the protos known to be on disk by directory, to report
+	// them deleted when their directory is moved or removed
+	protos map[string]map[string]bool
+	// closed is set by Close; a flush already underway reports nothing after it
+	closed bool
+}
+
+// newProtoWatcher starts watching ws, calling changed for every change
+func newProtoWatcher(ws *Workspace, changed func(ProtoFileEvent)) (*protoWatcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &protoWatcher{
+		ws:      ws,
+		watcher: fsw,
+		changed: changed,
+		pending: make(map[string]fsnotify.Op),
+		protos:  make(map[string]map[string]bool),
+	}
+
+	// The root is watched for gateway.yaml only
+	if err := fsw.Add(ws.Root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	for _, root := range ws.sourceRoots() {
+		w.addTree(root)
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// addTree watches dir and its subdirectories, except the skipped ones, and
+// records the protos they hold
+func (w *protoWatcher) addTree(dir string) {
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if w.watched(path) {
+				w.setKnown(path, true)
+			}
+			return nil
+		}
+		if path != dir && (skipTreeDir(d.Name()) || w.ws.excludedPath(path)) {
+			return filepath.SkipDir
+		}
+		if err := w.watcher.Add(path); err != nil {
+			fmt.Printf("Error watching %s: %v\n", path, err)
+		}
+		return nil
+	})
+}
+
+// run collects file system events until the watcher is closed
+func (w *protoWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Printf("Error watching workspace %s: %v\n", w.ws.Root, err)
+		}
+	}
+}
+
+// handle queues a relevant event for the next debounced flush
+func (w *protoWatcher) handle(event fsnotify.Event) {
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+
+	// Follow new directories, which may already hold protos
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if w.inSourceRoot(event.Name) && !skipTreeDir(info.Name()) && !w.ws.excludedPath(event.Name) {
+				w.addTree(event.Name)
+				filepath.WalkDir(event.Name, func(path string, d fs.DirEntry, err error) error {
+					if err == nil && !d.IsDir() && filepath.Ext(path) == ".proto" {
+						w.queue(path, fsnotify.Create)
+					}
+					return nil
+				})
+			}
+			return
+		}
+	}
+
+	if w.watched(event.Name) {
+		w.queue(event.Name, event.Op)
+		return
+	}
+
+	// A directory moved or removed takes its protos along, with no event
+	// of their own
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		for _, path := range w.knownUnder(event.Name) {
+			w.queue(path, fsnotify.Remove)
+		}
+	}
+}
+
+// setKnown records whether the proto at path is on disk
+func (w *protoWatcher) setKnown(path string, exists bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dir := filepath.Dir(path)
+	if exists {
+		if w.protos[dir] == nil {
+			w.protos[dir] = make(map[string]bool)
+		}
+		w.protos[dir][path] = true
+		return
+	}
+	delete(w.protos[dir], path)
+	if len(w.protos[dir]) == 0 {
+		delete(w.protos, dir)
+	}
+}
+
+// knownUnder returns the known protos in dir and its subdirectories
+func (w *protoWatcher) knownUnder(dir string) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var paths []string
+	for d, protos := range w.protos {
+		if !isWithin(d, dir) {
+			continue
+		}
+		for path := range protos {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// queue records an operation on path and restarts the debounce timer
+func (w *protoWatcher) queue(path string, op fsnotify.Op) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+
+	w.pending[path] |= op
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(watchDebounce, w.flush)
+}
+
+// flush reports the queued changes, unless the watcher was closed since
+// the debounce timer fired
+func (w *protoWatcher) flush() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	pending := w.pending
+	w.pending = make(map[string]fsnotify.Op)
+	w.mu.Unlock()
+
+	for path, op := range pending {
+		event := ProtoFileEvent{Workspace: w.ws.Root, Path: w.relPath(path), Gateway: filepath.Base(path) == gatewayConfigFile}
+
+		// The final state on disk decides the operation, so that editors
+		// saving through a rename do not look like deletions
+		_, err := os.Stat(path)
+		if !event.Gateway {
+			w.setKnown(path, err == nil)
+		}
+		switch {
+		case err != nil:
+			event.Op = FileDeleted
+		case op.Has(fsnotify.Create):
+			event.Op = FileCreated
+		default:
+			event.Op = FileModified
+		}
+		if w.isClosed() {
+			return
+		}
+		w.changed(event)
+	}
+}
+
+// isClosed reports whether Close was called
+func (w *protoWatcher) isClosed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closed
+}
+
+// watched reports whether a change to path is of interest: a proto below a
+// source root or the workspace gateway.yaml
+func (w *protoWatcher) watched(path string) bool {
+	if path == filepath.Join(w.ws.Root, gatewayConfigFile) {
+		return true
+	}
+	return filepath.Ext(path) == ".proto" && w.inSourceRoot(path) && !w.ws.excludedPath(path)
+}
+
+// inSourceRoot reports whether path is below a proto root of the workspace
+func (w *protoWatcher) inSourceRoot(path string) bool {
+	for _, root := range w.ws.sourceRoots() {
+		if isWithin(path, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// relPath returns the import path of a proto, relative to its source root,
+// or path relative to the workspace root when it lies outside of them
+func (w *protoWatcher) relPath(path string) string {
+	if root := w.ws.sourceRootOf(path); root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	rel, err := filepath.Rel(w.ws.Root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Close stops watching and drops the changes waiting for the debounce timer
+func (w *protoWatcher) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.pending = make(map[string]fsnotify.Op)
+	w.mu.Unlock()
+	return w.watcher.Close()
+}
+
+// stopWatching closes the workspace watcher
+func (a *App) stopWatching() {
+	a.watchMu.Lock()
+	defer a.watchMu.Unlock()
+	if a.watcher != nil {
+		a.watcher.Close()
+		a.watcher = nil
+	}
+}
+
+// contentHash returns the hex SHA-256 of content
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// watchWorkspace watches the active workspace, replacing the watcher of
+// the previously active one. It does nothing before the Wails runtime has
+// started, such as in tests.
+func (a *App) watchWorkspace() {
+	if a.ctx == nil {
+		return
+	}
+	ws, _ := a.currentWorkspace()
+
+	a.watchMu.Lock()
+	defer a.watchMu.Unlock()
+	if a.watcher != nil {
+		if a.watcher.ws == ws {
+			return
+		}
+		a.watcher.Close()
+		a.watcher = nil
+	}
+	if ws == nil {
+		return
+	}
+
+	watcher, err := newProtoWatcher(ws, a.protoChanged)
+	if err != nil {
+		fmt.Printf("Error watching workspace %s: %v\n", ws.Root, err)
+		return
+	}
+	a.watcher = watcher
+}
+
+// protoChanged sends a change to the frontend, unless it comes from the
+// watcher of a workspace that is no longer active
+func (a *App) protoChanged(event ProtoFileEvent) {
+	a.watchMu.Lock()
+	active := a.watcher != nil && a.watcher.ws.Root == event.Workspace
+	a.watchMu.Unlock()
+	if !active {
+		return
+	}
+	if event, ok := a.checkEditor(event); ok {
+		a.emit(EventPBChanged, event)
+	}
+}
+
+// checkEditor flags a conflict when event touches the proto open in the
+// editor with unsaved changes. It reports false for a change leaving the
+// file as the editor last loaded or saved it, such as the app's own save,
+// which is not worth reporting.
+func (a *App) checkEditor(event ProtoFileEvent) (ProtoFileEvent, bool) {
+	a.watchMu.Lock()
+	editor := a.editor
+	a.watchMu.Unlock()
+
+	if event.Gateway || editor.Workspace != event.Workspace || editor.File != event.Path {
+		return event, true
+	}
+	current := ""
+	if content, err := os.ReadFile(a.workspaceProtoPath(event.Workspace, event.Path)); err == nil {
+		current = contentHash(content)
+	}
+	if current == editor.Base {
+		return event, false
+	}
+	event.Conflict = editor.Dirty
+	return event, true
+}
+
+// workspaceProtoPath returns the path of the proto at the import path rel
+// in the open workspace at root
+func (a *App) workspaceProtoPath(root, rel string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if ws, ok := a.workspaces[root]; ok {
+		return ws.protoPath(rel)
+	}
+	return filepath.Join(root, defaultWorkspaceConfig().PBDir, filepath.FromSlash(rel))
+}
+
+// setEditorBase records content as what the editor holds for filename, if
+// it is the file open in the editor
+func (a *App) setEditorBase(ws *Workspace, filename string, content []byte) {
+	a.watchMu.Lock()
+	defer a.watchMu.Unlock()
+	if a.editor.Workspace == ws.Root && a.editor.File == cleanProtoPath(filename) {
+		a.editor.Base = contentHash(content)
+	}
+}
+
+// SetEditorState tells the backend which proto the editor shows and
+// whether it has unsaved changes, so external changes to it can be
+// reported as conflicts
+func (a *App) SetEditorState(filename string, dirty bool) error {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return err
+	}
+	filePath, err := ws.resolveProtoFile(filename)
+	if err != nil {
+		return err
+	}
+	file := cleanProtoPath(filename)
+
+	a.watchMu.Lock()
+	defer a.watchMu.Unlock()
+	if a.editor.Workspace != ws.Root || a.editor.File != file {
+		a.editor = editorState{Workspace: ws.Root, File: file}
+		if content, err := os.ReadFile(filePath); err == nil {
+			a.editor.Base = contentHash(content)
+		}
+	}
+	a.editor.Dirty = dirty
+	return nil
+}
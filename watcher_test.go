@@ -0,0 +1,184 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// TestProtoWatcher checks the events reported for protos, new directories
+// and gateway.yaml
+func TestProtoWatcher(t *testing.T) {
+	ws, err := LoadWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(ws.PBPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan ProtoFileEvent, 16)
+	w, err := newProtoWatcher(ws, func(event ProtoFileEvent) { events <- event })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	expect := func(path, op string, gateway bool) {
+		t.Helper()
+		select {
+		case event := <-events:
+			if event.Path != path || event.Op != op || event.Gateway != gateway || event.Workspace != ws.Root {
+				t.Fatalf("event = %+v, want %s %s", event, op, path)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event for %s %s", op, path)
+		}
+	}
+
+	writeFiles(t, ws.PBPath(), map[string]string{"a.proto": "syntax = \"proto3\";\n"})
+	expect("a.proto", FileCreated, false)
+	writeFiles(t, ws.PBPath(), map[string]string{"a.proto": "syntax = \"proto3\";\npackage a;\n"})
+	expect("a.proto", FileModified, false)
+
+	// Files of other kinds are ignored, protos in new directories are not
+	writeFiles(t, ws.PBPath(), map[string]string{"notes.txt": "ignored"})
+	writeFiles(t, ws.PBPath(), map[string]string{"sub/b.proto": "syntax = \"proto3\";\n"})
+	expect("sub/b.proto", FileCreated, false)
+	writeFiles(t, ws.PBPath(), map[string]string{"sub/b.proto": "syntax = \"proto3\";\npackage b;\n"})
+	expect("sub/b.proto", FileModified, false)
+
+	writeFiles(t, ws.Root, map[string]string{gatewayConfigFile: "type: google.api.Service\n"})
+	expect(gatewayConfigFile, FileCreated, true)
+
+	if err := os.Remove(filepath.Join(ws.PBPath(), "a.proto")); err != nil {
+		t.Fatal(err)
+	}
+	expect("a.proto", FileDeleted, false)
+}
+
+// TestProtoWatcher_DirMoved checks that the protos of a directory moved out
+// of the source root, including those present before watching started, are
+// reported deleted
+func TestProtoWatcher_DirMoved(t *testing.T) {
+	ws, err := LoadWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{
+		"keep.proto":           "syntax = \"proto3\";\n",
+		"foo/a.proto":          "syntax = \"proto3\";\n",
+		"foo/nested/b.proto":   "syntax = \"proto3\";\n",
+		"foo/nested/notes.txt": "ignored",
+	})
+
+	events := make(chan ProtoFileEvent, 16)
+	w, err := newProtoWatcher(ws, func(event ProtoFileEvent) { events <- event })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if err := os.Rename(filepath.Join(ws.PBPath(), "foo"), filepath.Join(t.TempDir(), "foo")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for len(got) < 2 {
+		select {
+		case event := <-events:
+			got[event.Path] = event.Op
+		case <-time.After(5 * time.Second):
+			t.Fatalf("events = %v, want the moved protos deleted", got)
+		}
+	}
+	want := map[string]string{"foo/a.proto": FileDeleted, "foo/nested/b.proto": FileDeleted}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	select {
+	case event := <-events:
+		t.Errorf("unexpected event %+v", event)
+	case <-time.After(2 * watchDebounce):
+	}
+}
+
+// TestCheckEditor checks that external changes to the proto open in the
+// editor are conflicts only when it has unsaved changes, and that the app's
+// own saves are not reported
+func TestCheckEditor(t *testing.T) {
+	app := NewApp()
+	ws, err := app.OpenWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, ws.PBPath(), map[string]string{"a.proto": "syntax = \"proto3\";\n"})
+
+	if err := app.SetEditorState("a.proto", false); err != nil {
+		t.Fatal(err)
+	}
+	event := ProtoFileEvent{Workspace: ws.Root, Path: "a.proto", Op: FileModified}
+	if _, ok := app.checkEditor(event); ok {
+		t.Error("unchanged file reported")
+	}
+
+	if _, err := app.SavePB("a.proto", "syntax = \"proto3\";\npackage saved;\n", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := app.checkEditor(event); ok {
+		t.Error("the app's own save reported")
+	}
+
+	writeFiles(t, ws.PBPath(), map[string]string{"a.proto": "syntax = \"proto3\";\npackage external;\n"})
+	if got, ok := app.checkEditor(event); !ok || got.Conflict {
+		t.Errorf("external change to a clean editor = %+v, %v; want a change without conflict", got, ok)
+	}
+
+	if err := app.SetEditorState("a.proto", true); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := app.checkEditor(event); !ok || !got.Conflict {
+		t.Errorf("external change to a dirty editor = %+v, %v; want a conflict", got, ok)
+	}
+
+	other := ProtoFileEvent{Workspace: ws.Root, Path: "b.proto", Op: FileCreated}
+	if got, ok := app.checkEditor(other); !ok || got.Conflict {
+		t.Errorf("change to another file = %+v, %v; want a change without conflict", got, ok)
+	}
+}
+
+// TestProtoWatcher_Close checks that changes waiting for the debounce timer,
+// or for a flush already underway, are dropped once the watcher is closed
+func TestProtoWatcher_Close(t *testing.T) {
+	ws, err := LoadWorkspace(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(ws.PBPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan ProtoFileEvent, 16)
+	w, err := newProtoWatcher(ws, func(event ProtoFileEvent) { events <- event })
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(ws.PBPath(), "a.proto")
+	w.queue(path, fsnotify.Create)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// A timer that fired before Close flushes after it
+	w.flush()
+	w.queue(path, fsnotify.Write)
+
+	select {
+	case event := <-events:
+		t.Errorf("event after Close: %+v", event)
+	case <-time.After(3 * watchDebounce):
+	}
+}
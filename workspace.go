@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// workspaceEnvVar overrides the persisted workspace root when set
+	workspaceEnvVar = "PB_TOOL_WORKSPACE"
+	// workspaceConfigFile is the per-workspace settings file, relative to the workspace root
+	workspaceConfigFile = "pb-tool.json"
+	// appConfigFile is the user-level settings file inside the pb-tool config directory
+	appConfigFile = "config.json"
+)
+
+// errNoWorkspace is returned by file APIs when no workspace has been selected yet
+var errNoWorkspace = errors.New("no workspace selected")
+
+// WorkspaceConfig holds the per-workspace directory layout and generation
+// pipeline. Relative paths are resolved against the workspace root.
+type WorkspaceConfig struct {
+	PBDir      string `json:"pbDir"`
+	OutputDir  string `json:"outputDir"`
+	IncludeDir string `json:"includeDir"`
+	// Protoc optionally names a protoc binary used for validation instead
+	// of the built-in compiler
+	Protoc string `json:"protoc,omitempty"`
+	// Plugins is the generation pipeline; empty means the default
+	// go, go-grpc and grpc-gateway plugins
+	Plugins []PluginConfig `json:"plugins,omitempty"`
+	// FormatOnSave formats protos with FormatPB when they are saved
+	FormatOnSave bool `json:"formatOnSave,omitempty"`
+	// Lint sets the severity of lint rules by ID: error, warning or off.
+	// Rules not listed report warnings.
+	Lint map[string]string `json:"lint,omitempty"`
+}
+
+// defaultWorkspaceConfig returns the layout used when pb-tool.json is missing
+func defaultWorkspaceConfig() WorkspaceConfig {
+	return WorkspaceConfig{
+		PBDir:      "pb",
+		OutputDir:  filepath.Join("grpc_output", "pb"),
+		IncludeDir: "include",
+	}
+}
+
+// Workspace is a proto project root together with its directory layout.
+// Buf holds the buf.yaml and buf.gen.yaml settings when the root has them.
+type Workspace struct {
+	Name   string          `json:"name"`
+	Root   string          `json:"root"`
+	Config WorkspaceConfig `json:"config"`
+	Buf    *BufConfig      `json:"buf,omitempty"`
+}
+
+// LoadWorkspace opens the workspace at root, reading pb-tool.json and the
+// buf configs if present. Settings in pb-tool.json take precedence.
+func LoadWorkspace(root string) (*Workspace, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolving workspace root %s: %w", root, err)
+	}
+
+	info, err := os.Stat(absRoot)
+	if err != nil {
+		return nil, fmt.Errorf("opening workspace %s: %w", absRoot, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("workspace %s is not a directory", absRoot)
+	}
+
+	var cfg WorkspaceConfig
+	content, err := os.ReadFile(filepath.Join(absRoot, workspaceConfigFile))
+	if err == nil {
+		if err := json.Unmarshal(content, &cfg); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", workspaceConfigFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading %s: %w", workspaceConfigFile, err)
+	}
+
+	buf, err := loadBufConfig(absRoot)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.PBDir == "" && buf != nil && len(buf.Modules) > 0 {
+		cfg.PBDir = filepath.FromSlash(buf.Modules[0].Path)
+	}
+	cfg = cfg.withDefaults()
+
+	return &Workspace{
+		Name:   filepath.Base(absRoot),
+		Root:   absRoot,
+		Config: cfg,
+		Buf:    buf,
+	}, nil
+}
+
+// withDefaults fills empty layout entries with their default locations
+func (c WorkspaceConfig) withDefaults() WorkspaceConfig {
+	defaults := defaultWorkspaceConfig()
+	if c.PBDir == "" {
+		c.PBDir = defaults.PBDir
+	}
+	if c.OutputDir == "" {
+		c.OutputDir = defaults.OutputDir
+	}
+	if c.IncludeDir == "" {
+		c.IncludeDir = defaults.IncludeDir
+	}
+	return c
+}
+
+// merge returns the config with the JSON fields of update applied over it
+func (c WorkspaceConfig) merge(update map[string]interface{}) (WorkspaceConfig, error) {
+	content, err := json.Marshal(update)
+	if err != nil {
+		return c, fmt.Errorf("encoding workspace config: %w", err)
+	}
+	// Decoding into the copy replaces only the fields present in update.
+	// Decoding reuses maps and slices, which the workspace still shares.
+	if _, ok := update["plugins"]; ok {
+		c.Plugins = nil
+	}
+	if _, ok := update["lint"]; ok {
+		c.Lint = nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("invalid workspace config: %w", err)
+	}
+	return c.withDefaults(), nil
+}
+
+// SaveConfig writes the workspace layout to pb-tool.json
+func (w *Workspace) SaveConfig() error {
+	content, err := json.MarshalIndent(w.Config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling workspace config: %w", err)
+	}
+	return os.WriteFile(filepath.Join(w.Root, workspaceConfigFile), content, 0644)
+}
+
+// path resolves a layout entry against the workspace root
+func (w *Workspace) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(w.Root, p)
+}
+
+// PBPath returns the absolute directory holding the workspace protos
+func (w *Workspace) PBPath() string {
+	return w.path(w.Config.PBDir)
+}
+
+// OutputPath returns the absolute directory generated code is written to
+func (w *Workspace) OutputPath() string {
+	return w.path(w.Config.OutputDir)
+}
+
+// IncludePath returns the absolute directory holding third-party protos
+func (w *Workspace) IncludePath() string {
+	return w.path(w.Config.IncludeDir)
+}
+
+// looksLikeWorkspace reports whether dir has a pb-tool.json, a buf config
+// or a pb directory
+func looksLikeWorkspace(dir string) bool {
+	for _, name := range []string{workspaceConfigFile, bufModuleFile, bufWorkFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	info, err := os.Stat(filepath.Join(dir, defaultWorkspaceConfig().PBDir))
+	return err == nil && info.IsDir()
+}
+
+// maxRecentWorkspaces bounds the recent-projects list
+const maxRecentWorkspaces = 20
+
+// RecentWorkspace is an entry of the recent-projects list
+type RecentWorkspace struct {
+	Name     string    `json:"name"`
+	Root     string    `json:"root"`
+	OpenedAt time.Time `json:"openedAt"`
+}
+
+// appConfig is the user-level state persisted between launches
+type appConfig struct {
+	Workspace string            `json:"workspace"`
+	Open      []string          `json:"open"`
+	Recent    []RecentWorkspace `json:"recent"`
+}
+
+// addRecent moves ws to the front of the recent-projects list
+func (c *appConfig) addRecent(ws *Workspace) {
+	recent := []RecentWorkspace{{Name: ws.Name, Root: ws.Root, OpenedAt: time.Now()}}
+	for _, r := range c.Recent {
+		if r.Root != ws.Root && len(recent) < maxRecentWorkspaces {
+			recent = append(recent, r)
+		}
+	}
+	c.Recent = recent
+}
+
+// appConfigDir returns the pb-tool directory inside the user's config dir
+func appConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pb-tool"), nil
+}
+
+// loadAppConfig loads the user-level config from dir
+func loadAppConfig(dir string) appConfig {
+	var cfg appConfig
+
+	content, err := os.ReadFile(filepath.Join(dir, appConfigFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Printf("Error reading app config: %v\n", err)
+		}
+		return cfg
+	}
+
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		fmt.Printf("Error parsing app config: %v\n", err)
+	}
+	return cfg
+}
+
+// saveAppConfig writes the user-level config to dir
+func saveAppConfig(dir string, cfg appConfig) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, appConfigFile), content, 0644)
+}
+
+// resolveWorkspace reopens the workspaces left open at the last launch and
+// picks the active one from the environment, the persisted config or the
+// current directory, in that order
+func (a *App) resolveWorkspace() {
+	for _, root := range a.config.Open {
+		ws, err := LoadWorkspace(root)
+		if err != nil {
+			fmt.Printf("Error reopening workspace %s: %v\n", root, err)
+			continue
+		}
+		a.workspaces[ws.Root] = ws
+	}
+
+	candidates := []string{os.Getenv(workspaceEnvVar), a.config.Workspace}
+	if cwd, err := os.Getwd(); err == nil && looksLikeWorkspace(cwd) {
+		candidates = append(candidates, cwd)
+	}
+
+	for _, root := range candidates {
+		if root == "" {
+			continue
+		}
+		if _, err := a.OpenWorkspace(root); err != nil {
+			fmt.Printf("Error loading workspace %s: %v\n", root, err)
+			continue
+		}
+		return
+	}
+
+	// Fall back to any workspace reopened from the last launch
+	a.mu.Lock()
+	for _, ws := range a.sortedWorkspaces() {
+		a.workspace = ws
+		break
+	}
+	a.mu.Unlock()
+	a.saveWorkspaceState()
+	a.watchWorkspace()
+}
+
+// saveWorkspaceState persists the open and active workspaces
+func (a *App) saveWorkspaceState() {
+	a.mu.Lock()
+	open := make([]string, 0, len(a.workspaces))
+	for root := range a.workspaces {
+		open = append(open, root)
+	}
+	sort.Strings(open)
+	a.config.Open = open
+	a.config.Workspace = ""
+	if a.workspace != nil {
+		a.config.Workspace = a.workspace.Root
+	}
+	cfg := a.config
+	a.mu.Unlock()
+
+	if a.configDir == "" {
+		return
+	}
+	if err := saveAppConfig(a.configDir, cfg); err != nil {
+		fmt.Printf("Error saving app config: %v\n", err)
+	}
+}
+
+// currentWorkspace returns the active workspace or errNoWorkspace
+func (a *App) currentWorkspace() (*Workspace, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.workspace == nil {
+		return nil, errNoWorkspace
+	}
+	return a.workspace, nil
+}
+
+// GetWorkspace returns the active workspace, or nil if none is selected
+func (a *App) GetWorkspace() *Workspace {
+	ws, _ := a.currentWorkspace()
+	return ws
+}
+
+// ChooseWorkspace asks the user for a workspace directory and opens it.
+// It returns nil without error when the dialog is cancelled.
+func (a *App) ChooseWorkspace() (*Workspace, error) {
+	dir, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:                "Select pb-tool workspace",
+		CanCreateDirectories: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return nil, nil
+	}
+	return a.OpenWorkspace(dir)
+}
+
+// OpenWorkspace opens the workspace rooted at root, makes it the active
+// workspace and records it in the recent-projects list
+func (a *App) OpenWorkspace(root string) (*Workspace, error) {
+	ws, err := LoadWorkspace(root)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.workspaces[ws.Root] = ws
+	a.workspace = ws
+	a.config.addRecent(ws)
+	a.mu.Unlock()
+
+	a.saveWorkspaceState()
+	a.watchWorkspace()
+	return ws, nil
+}
+
+// CloseWorkspace closes an open workspace. Closing the active workspace
+// activates another open one, if any.
+func (a *App) CloseWorkspace(root string) error {
+	a.mu.Lock()
+	if _, ok := a.workspaces[root]; !ok {
+		a.mu.Unlock()
+		return fmt.Errorf("workspace %s is not open", root)
+	}
+	delete(a.workspaces, root)
+
+	if a.workspace != nil && a.workspace.Root == root {
+		a.workspace = nil
+		for _, ws := range a.sortedWorkspaces() {
+			a.workspace = ws
+			break
+		}
+	}
+	a.mu.Unlock()
+
+	a.saveWorkspaceState()
+	a.watchWorkspace()
+	return nil
+}
+
+// SwitchWorkspace makes an already open workspace the active one
+func (a *App) SwitchWorkspace(root string) (*Workspace, error) {
+	a.mu.Lock()
+	ws, ok := a.workspaces[root]
+	if !ok {
+		a.mu.Unlock()
+		return nil, fmt.Errorf("workspace %s is not open", root)
+	}
+	a.workspace = ws
+	a.config.addRecent(ws)
+	a.mu.Unlock()
+
+	a.saveWorkspaceState()
+	a.watchWorkspace()
+	return ws, nil
+}
+
+// ListWorkspaces returns the open workspaces sorted by name
+func (a *App) ListWorkspaces() []*Workspace {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.sortedWorkspaces()
+}
+
+// sortedWorkspaces returns the open workspaces sorted by name; callers hold a.mu
+func (a *App) sortedWorkspaces() []*Workspace {
+	list := make([]*Workspace, 0, len(a.workspaces))
+	for _, ws := range a.workspaces {
+		list = append(list, ws)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].Root < list[j].Root
+	})
+	return list
+}
+
+// GetRecentWorkspaces returns recently opened workspaces, most recent first
+func (a *App) GetRecentWorkspaces() []RecentWorkspace {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]RecentWorkspace(nil), a.config.Recent...)
+}
+
+// UpdateWorkspaceConfig merges settings into the config of the active
+// workspace and saves it to its pb-tool.json. update holds the JSON fields
+// of WorkspaceConfig to change; fields left out keep their value and empty
+// or null values restore the defaults.
+func (a *App) UpdateWorkspaceConfig(update map[string]interface{}) (*Workspace, error) {
+	ws, err := a.currentWorkspace()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := ws.Config.merge(update)
+	if err != nil {
+		return nil, err
+	}
+	updated := *ws
+	updated.Config = cfg
+	if err := updated.SaveConfig(); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.workspaces[updated.Root] = &updated
+	if a.workspace == ws {
+		a.workspace = &updated
+	}
+	a.mu.Unlock()
+	a.watchWorkspace()
+	return &updated, nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestLoadWorkspace checks layout defaults and pb-tool.json overrides
+func TestLoadWorkspace(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		wantPB     string
+		wantOutput string
+	}{
+		{"no config file", "", "pb", filepath.Join("grpc_output", "pb")},
+		{"custom pb dir", `{"pbDir": "proto"}`, "proto", filepath.Join("grpc_output", "pb")},
+		{"custom output dir", `{"outputDir": "gen"}`, "pb", "gen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if tt.config != "" {
+				if err := os.WriteFile(filepath.Join(root, workspaceConfigFile), []byte(tt.config), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ws, err := LoadWorkspace(root)
+			if err != nil {
+				t.Fatalf("LoadWorkspace: %v", err)
+			}
+			if got := ws.PBPath(); got != filepath.Join(root, tt.wantPB) {
+				t.Errorf("PBPath = %s, want %s", got, filepath.Join(root, tt.wantPB))
+			}
+			if got := ws.OutputPath(); got != filepath.Join(root, tt.wantOutput) {
+				t.Errorf("OutputPath = %s, want %s", got, filepath.Join(root, tt.wantOutput))
+			}
+			if got := ws.IncludePath(); got != filepath.Join(root, "include") {
+				t.Errorf("IncludePath = %s, want %s", got, filepath.Join(root, "include"))
+			}
+		})
+	}
+}
+
+// TestLoadWorkspace_NotDirectory checks that a file cannot be opened as a workspace
+func TestLoadWorkspace_NotDirectory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadWorkspace(file); err == nil {
+		t.Error("expected error for non-directory workspace root")
+	}
+}
+
+// TestWorkspaceSwitching checks opening, switching and closing workspaces
+func TestWorkspaceSwitching(t *testing.T) {
+	app := NewApp()
+	first, second := t.TempDir(), t.TempDir()
+
+	if _, err := app.OpenWorkspace(first); err != nil {
+		t.Fatalf("OpenWorkspace: %v", err)
+	}
+	if _, err := app.OpenWorkspace(second); err != nil {
+		t.Fatalf("OpenWorkspace: %v", err)
+	}
+	if got := app.GetWorkspace().Root; got != second {
+		t.Errorf("active workspace = %s, want %s", got, second)
+	}
+	if got := len(app.ListWorkspaces()); got != 2 {
+		t.Errorf("open workspaces = %d, want 2", got)
+	}
+
+	if _, err := app.SwitchWorkspace(first); err != nil {
+		t.Fatalf("SwitchWorkspace: %v", err)
+	}
+	recent := app.GetRecentWorkspaces()
+	if len(recent) != 2 || recent[0].Root != first {
+		t.Errorf("recent workspaces = %+v, want %s first", recent, first)
+	}
+
+	if err := app.CloseWorkspace(first); err != nil {
+		t.Fatalf("CloseWorkspace: %v", err)
+	}
+	if got := app.GetWorkspace().Root; got != second {
+		t.Errorf("active workspace after close = %s, want %s", got, second)
+	}
+	if _, err := app.SwitchWorkspace(first); err == nil {
+		t.Error("expected error switching to a closed workspace")
+	}
+}
+
+// TestUpdateWorkspaceConfig checks that updates keep the fields they leave out
+func TestUpdateWorkspaceConfig(t *testing.T) {
+	app := NewApp()
+	if _, err := app.OpenWorkspace(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	plugins := []PluginConfig{{Name: "go", Out: "gen"}}
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"plugins": plugins, "lint": map[string]string{LintGoPackage: LintOff}}); err != nil {
+		t.Fatal(err)
+	}
+
+	ws, err := app.UpdateWorkspaceConfig(map[string]interface{}{"formatOnSave": true, "outputDir": "out"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ws.Config.FormatOnSave || ws.Config.OutputDir != "out" || len(ws.Config.Plugins) != 1 || ws.Config.Lint[LintGoPackage] != LintOff || ws.Config.PBDir != "pb" {
+		t.Errorf("merged config = %+v", ws.Config)
+	}
+	reloaded, err := LoadWorkspace(ws.Root)
+	if err != nil || !reflect.DeepEqual(reloaded.Config, ws.Config) {
+		t.Errorf("saved config = %+v, %v; want %+v", reloaded.Config, err, ws.Config)
+	}
+
+	// Empty values restore the defaults
+	ws, err = app.UpdateWorkspaceConfig(map[string]interface{}{"plugins": nil, "outputDir": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Config.Plugins != nil || ws.Config.OutputDir != defaultWorkspaceConfig().OutputDir || !ws.Config.FormatOnSave {
+		t.Errorf("reset config = %+v", ws.Config)
+	}
+
+	if _, err := app.UpdateWorkspaceConfig(map[string]interface{}{"pbdirr": "proto"}); err == nil {
+		t.Error("accepted an unknown config field")
+	}
+}
+
+// TestMigrateUserData checks that the legacy data files are copied once,
+// without overwriting data already in the new directory
+func TestMigrateUserData(t *testing.T) {
+	legacy, dataDir := t.TempDir(), t.TempDir()
+	writeFiles(t, legacy, map[string]string{"users.json": `{"a":{}}`, "sessions.json": `{"s":{}}`})
+	writeFiles(t, dataDir, map[string]string{"sessions.json": `{}`})
+
+	migrateUserData(legacy, dataDir)
+	for name, want := range map[string]string{"users.json": `{"a":{}}`, "sessions.json": `{}`} {
+		got, err := os.ReadFile(filepath.Join(dataDir, name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+}